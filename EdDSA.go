@@ -0,0 +1,88 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+)
+
+// EdDSA provides methods for signing and verifying JWTs with EdDSA using Ed25519.
+type EdDSA struct {
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+}
+
+// NewEdDSA creates a new EdDSA helper from an Ed25519 private key. The private key must be PEM encoded (PKCS #8).
+func NewEdDSA(key []byte) (*EdDSA, error) {
+	if key == nil {
+		return nil, errors.New("Key is empty")
+	}
+	block, rest := pem.Decode(key)
+	if block == nil {
+		return nil, errors.New("Could not parse private key from PEM file")
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("Multiple blocks per key file are not supported")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("Private key is not an Ed25519 key")
+	}
+	return newEdDSAFromKey(privateKey), nil
+}
+
+func newEdDSAFromKey(privateKey ed25519.PrivateKey) *EdDSA {
+	return &EdDSA{privateKey, privateKey.Public().(ed25519.PublicKey)}
+}
+
+// Sign signs arbitrary data and returns a signature.
+func (e *EdDSA) Sign(data []byte) ([]byte, error) {
+	if data == nil {
+		return nil, errors.New("Data to be signed can't be empty")
+	}
+	if e.privateKey == nil {
+		return nil, ErrVerifyOnly
+	}
+	return ed25519.Sign(e.privateKey, data), nil
+}
+
+// Verify verifies signed data.
+func (e *EdDSA) Verify(data []byte, signature []byte) error {
+	if !ed25519.Verify(e.publicKey, data, signature) {
+		return errors.New("Token could not be verified")
+	}
+	return nil
+}
+
+// Name returns the the JWT algorithm name.
+func (e *EdDSA) Name() string {
+	return JWT_EdDSA
+}
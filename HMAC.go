@@ -24,42 +24,90 @@ SOFTWARE.
 package jwt
 
 import (
+	"bytes"
 	"crypto"
 	"crypto/hmac"
+	"crypto/x509"
+	"encoding/pem"
 	"errors"
+	"hash"
+	"strconv"
+	"sync"
 )
 
-// ES512 provides methods for signing and verifying JWTs with ECDSA521 and SHA512
+// HMAC provides methods for signing and verifying JWTs with HMAC. Keyed hash states are
+// pooled per instance.
 type HMAC struct {
 	hash   crypto.Hash
 	secret []byte
 	name   string
+	pool   sync.Pool
 }
 
 func newHMAC(name string, secret []byte, hash crypto.Hash) (*HMAC, error) {
-	if secret == nil {
+	if len(secret) == 0 {
 		return nil, errors.New("Secret or private key can't be empty")
 	}
-	return &HMAC{hash, secret, name}, nil
+	// RFC 7518 section 3.2: a key of the same size as the hash output or larger must be used.
+	if len(secret) < hash.Size() {
+		return nil, errors.New("HMAC secret must have at least " + strconv.Itoa(hash.Size()) + " bytes")
+	}
+	// Rejecting encoded asymmetric keys catches configuration mistakes such as passing a public
+	// RSA key as secret. It doesn't bind keys to algorithms, callers must still pick the
+	// algorithm from their configuration and not from the token header.
+	if block, _ := pem.Decode(secret); block != nil || bytes.Contains(secret, []byte("-----BEGIN")) {
+		return nil, errors.New("PEM encoded keys can't be used as HMAC secret")
+	}
+	if isDERKey(secret) {
+		return nil, errors.New("DER encoded keys can't be used as HMAC secret")
+	}
+	return &HMAC{hash: hash, secret: secret, name: name}, nil
+}
+
+// isDERKey reports whether data parses as a DER encoded public key, private key or certificate.
+func isDERKey(data []byte) bool {
+	if _, err := x509.ParsePKIXPublicKey(data); err == nil {
+		return true
+	}
+	if _, err := x509.ParsePKCS1PublicKey(data); err == nil {
+		return true
+	}
+	if _, err := x509.ParsePKCS8PrivateKey(data); err == nil {
+		return true
+	}
+	if _, err := x509.ParsePKCS1PrivateKey(data); err == nil {
+		return true
+	}
+	if _, err := x509.ParseECPrivateKey(data); err == nil {
+		return true
+	}
+	_, err := x509.ParseCertificate(data)
+	return err == nil
+}
+
+// sum returns the MAC of data using a pooled hash state.
+func (e *HMAC) sum(data []byte) []byte {
+	mac, ok := e.pool.Get().(hash.Hash)
+	if ok {
+		mac.Reset()
+	} else {
+		mac = hmac.New(e.hash.New, e.secret)
+	}
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	e.pool.Put(mac)
+	return sum
 }
 
 func (e *HMAC) sign(data []byte) ([]byte, error) {
 	if data == nil {
 		return nil, errors.New("Data to be signed can't be empty")
 	}
-	hash := e.hash.New()
-	_, err := hash.Write(data)
-	if err != nil {
-		return nil, err
-	}
-	return hash.Sum(nil), nil
+	return e.sum(data), nil
 }
 
 func (e *HMAC) verify(data, mac []byte) error {
-	hash := e.hash.New()
-	hash.Write(data)
-	sum := hash.Sum(nil)
-	verified := hmac.Equal(mac, sum)
+	verified := hmac.Equal(mac, e.sum(data))
 	if verified == false {
 		return errors.New("Token could not be verified")
 	}
@@ -25,12 +25,14 @@ package jwt
 
 import (
 	"crypto"
+	"encoding/base64"
+	"encoding/pem"
 	"testing"
 )
 
 func TestHMAC(t *testing.T) {
 	data := []byte("test")
-	hmac, err := newHMAC(JWT_HS256, []byte("test"), crypto.SHA256)
+	hmac, err := newHMAC(JWT_HS256, testSecret("test"), crypto.SHA256)
 	if err != nil {
 		t.Log(err)
 		t.Fail()
@@ -47,8 +49,30 @@ func TestHMAC(t *testing.T) {
 	}
 }
 
+// TestHMACKnownAnswer verifies the HS256 example of RFC 7515 appendix A.1.
+func TestHMACKnownAnswer(t *testing.T) {
+	secret, _ := base64.RawURLEncoding.DecodeString("AyM1SysPpbyDfgZld3umj1qzKObwVMkoqQ-EstJQLr_T-1qS0gZH75aKtMN3Yj0iPS4hcgUuTwjAzZr1Z9CAow")
+	input := "eyJ0eXAiOiJKV1QiLA0KICJhbGciOiJIUzI1NiJ9" +
+		".eyJpc3MiOiJqb2UiLA0KICJleHAiOjEzMDA4MTkzODAsDQogImh0dHA6Ly9leGFtcGxlLmNvbS9pc19yb290Ijp0cnVlfQ"
+	expected, _ := base64.RawURLEncoding.DecodeString("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")
+	hmac, err := NewHS256(secret)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	signed, err := hmac.Sign([]byte(input))
+	if err != nil || string(signed) != string(expected) {
+		t.Log(base64.RawURLEncoding.EncodeToString(signed), err)
+		t.Fail()
+	}
+	if err := hmac.Verify([]byte(input), expected); err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
 func TestSignNilData(t *testing.T) {
-	hmac, err := newHMAC(JWT_HS256, []byte("test"), crypto.SHA256)
+	hmac, err := newHMAC(JWT_HS256, testSecret("test"), crypto.SHA256)
 	_, err = hmac.sign(nil)
 	if err == nil {
 		t.Log(err)
@@ -64,11 +88,58 @@ func TestHMACNilSecret(t *testing.T) {
 	}
 }
 
+// TestHMACShortSecret verifies that secrets shorter than the hash output are rejected (RFC 7518 section 3.2).
+func TestHMACShortSecret(t *testing.T) {
+	tests := []struct {
+		alg    string
+		secret []byte
+	}{
+		{JWT_HS256, []byte{}},
+		{JWT_HS256, testSecret("short")[:31]},
+		{JWT_HS348, testSecret("short")[:47]},
+		{JWT_HS512, testSecret("short")[:63]},
+	}
+	for _, test := range tests {
+		if _, err := NewAlgorithm(test.alg, test.secret); err == nil {
+			t.Logf("%s accepted a %d byte secret", test.alg, len(test.secret))
+			t.Fail()
+		}
+	}
+	for _, alg := range []string{JWT_HS256, JWT_HS348, JWT_HS512} {
+		if _, err := NewAlgorithm(alg, testSecret("short")); err != nil {
+			t.Log(err)
+			t.Fail()
+		}
+	}
+}
+
+func TestHMACEncodedKeySecret(t *testing.T) {
+	publicKey, _ := readFixture("rsa.pub")
+	block, _ := pem.Decode(publicKey)
+	for _, secret := range [][]byte{publicKey, block.Bytes} {
+		if _, err := newHMAC(JWT_HS256, secret, crypto.SHA256); err == nil {
+			t.Log(string(secret))
+			t.Fail()
+		}
+	}
+}
+
 func TestHMACVerifyFailure(t *testing.T) {
-	hmac, err := newHMAC(JWT_HS256, []byte("secret"), crypto.SHA256)
+	hmac, err := newHMAC(JWT_HS256, testSecret("secret"), crypto.SHA256)
 	err = hmac.verify([]byte("invalid"), []byte("invalid"))
 	if err == nil {
 		t.Log(err)
 		t.Fail()
 	}
 }
+
+func TestHMACWrongSecret(t *testing.T) {
+	hmac1, _ := newHMAC(JWT_HS256, testSecret("secret1"), crypto.SHA256)
+	hmac2, _ := newHMAC(JWT_HS256, testSecret("secret2"), crypto.SHA256)
+	signed, _ := hmac1.sign([]byte("test"))
+	err := hmac2.verify([]byte("test"), signed)
+	if err == nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
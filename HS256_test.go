@@ -26,7 +26,7 @@ package jwt
 import "testing"
 
 func TestHS256(t *testing.T) {
-	alg, err := NewHS256([]byte("test"))
+	alg, err := NewHS256(testSecret("test"))
 	if err != nil {
 		t.Log(err)
 		t.Fail()
@@ -26,7 +26,7 @@ package jwt
 import "testing"
 
 func TestHS384(t *testing.T) {
-	alg, err := NewHS384([]byte("test"))
+	alg, err := NewHS384(testSecret("test"))
 	if err != nil {
 		t.Log(err)
 		t.Fail()
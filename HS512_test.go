@@ -26,7 +26,7 @@ package jwt
 import "testing"
 
 func TestHS512(t *testing.T) {
-	alg, err := NewHS512([]byte("test"))
+	alg, err := NewHS512(testSecret("test"))
 	if err != nil {
 		t.Log(err)
 		t.Fail()
@@ -0,0 +1,324 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strconv"
+)
+
+const (
+	JWE_PBES2_HS256_A128KW = "PBES2-HS256+A128KW"
+	JWE_PBES2_HS512_A256KW = "PBES2-HS512+A256KW"
+)
+
+const (
+	// PBES2_MIN_ITERATIONS is the lowest iteration count accepted when encrypting (RFC 7518 section 4.8.1.2).
+	PBES2_MIN_ITERATIONS = 1000
+	// PBES2_MAX_ITERATIONS is the default upper bound for the "p2c" header accepted when decrypting.
+	PBES2_MAX_ITERATIONS = 1000000
+	// PBES2_SALT_SIZE is the size of the random salt input in bytes.
+	PBES2_SALT_SIZE = 16
+)
+
+const (
+	CTY_JWT = "JWT"
+	CTY_JWK = "jwk+json"
+	CTY_PEM = "application/x-pem-file"
+)
+
+var aesKeyWrapIV = []byte{0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6}
+
+// PBES2 provides methods for encrypting and decrypting tokens and keys with a password.
+// Supported key management algorithms are PBES2-HS256+A128KW and PBES2-HS512+A256KW.
+// A PBES2 may be used from multiple goroutines once it is configured.
+type PBES2 struct {
+	password      []byte
+	iterations    int
+	maxIterations int
+	hash          crypto.Hash
+	keySize       int
+	enc           string
+	name          string
+}
+
+func newPBES2(name string, password []byte, iterations int, hash crypto.Hash, keySize int, enc string) (*PBES2, error) {
+	if len(password) == 0 {
+		return nil, errors.New("Password can't be empty")
+	}
+	if iterations < PBES2_MIN_ITERATIONS {
+		return nil, errors.New("Iteration count must be at least " + strconv.Itoa(PBES2_MIN_ITERATIONS))
+	}
+	maxIterations := PBES2_MAX_ITERATIONS
+	if iterations > maxIterations {
+		maxIterations = iterations
+	}
+	return &PBES2{password, iterations, maxIterations, hash, keySize, enc, name}, nil
+}
+
+// NewPBES2HS256A128KW creates a new PBES2-HS256+A128KW helper from a password. Content is encrypted with A128GCM.
+func NewPBES2HS256A128KW(password []byte, iterations int) (*PBES2, error) {
+	return newPBES2(JWE_PBES2_HS256_A128KW, password, iterations, crypto.SHA256, 16, JWE_A128GCM)
+}
+
+// NewPBES2HS512A256KW creates a new PBES2-HS512+A256KW helper from a password. Content is encrypted with A256GCM.
+func NewPBES2HS512A256KW(password []byte, iterations int) (*PBES2, error) {
+	return newPBES2(JWE_PBES2_HS512_A256KW, password, iterations, crypto.SHA512, 32, JWE_A256GCM)
+}
+
+// SetMaxIterations sets the highest "p2c" value accepted when decrypting. It must be called
+// before the PBES2 is first used, it is not safe to call concurrently with Encrypt or Decrypt.
+func (p *PBES2) SetMaxIterations(max int) error {
+	if max < PBES2_MIN_ITERATIONS {
+		return errors.New("Maximum iteration count must be at least " + strconv.Itoa(PBES2_MIN_ITERATIONS))
+	}
+	p.maxIterations = max
+	return nil
+}
+
+// Name returns the JWE key management algorithm name.
+func (p *PBES2) Name() string {
+	return p.name
+}
+
+// Encrypt encrypts arbitrary data and returns a JWE in compact serialization.
+// The content type is stored in the "cty" header and may be empty.
+func (p *PBES2) Encrypt(plaintext []byte, contentType string) (string, error) {
+	salt := make([]byte, PBES2_SALT_SIZE)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	cek := make([]byte, JWE_ENC_KEY_SIZE[p.enc])
+	if _, err := rand.Read(cek); err != nil {
+		return "", err
+	}
+	kek := p.deriveKey(salt, p.iterations)
+	encryptedKey, err := aesKeyWrap(kek, cek)
+	if err != nil {
+		return "", err
+	}
+	header := &JweHeader{
+		Alg: p.name,
+		Enc: p.enc,
+		Cty: contentType,
+		P2s: base64.RawURLEncoding.EncodeToString(salt),
+		P2c: p.iterations,
+	}
+	return sealJWE(header, encryptedKey, cek, plaintext)
+}
+
+// Decrypt decrypts a JWE created by Encrypt and returns the plaintext.
+func (p *PBES2) Decrypt(token string) ([]byte, error) {
+	plaintext, _, err := p.decrypt(token)
+	return plaintext, err
+}
+
+func (p *PBES2) decrypt(token string) ([]byte, *JweHeader, error) {
+	e, err := parseJWE(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if e.header.Alg != p.name {
+		return nil, nil, errors.New("Invalid JWE algorithm")
+	}
+	if e.header.Enc != p.enc {
+		return nil, nil, errors.New("Invalid JWE content encryption algorithm")
+	}
+	if e.header.P2c < 1 || e.header.P2c > p.maxIterations {
+		return nil, nil, errors.New("JWE iteration count is out of the accepted range")
+	}
+	salt, err := base64URL.DecodeString(e.header.P2s)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(salt) < 8 {
+		return nil, nil, errors.New("JWE salt input is too short")
+	}
+	kek := p.deriveKey(salt, e.header.P2c)
+	cek, err := aesKeyUnwrap(kek, e.encryptedKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	plaintext, err := e.open(cek)
+	if err != nil {
+		return nil, nil, err
+	}
+	return plaintext, &e.header, nil
+}
+
+// deriveKey derives the key encryption key. The salt is prefixed with the
+// algorithm name as described in RFC 7518 section 4.8.1.1.
+func (p *PBES2) deriveKey(salt []byte, iterations int) []byte {
+	input := append(append([]byte(p.name), 0), salt...)
+	return pbkdf2(p.hash, p.password, input, iterations, p.keySize)
+}
+
+// EncryptToken encrypts a JWT with a password. The JWT becomes the payload of a nested JWE.
+func EncryptToken(token string, p *PBES2) (string, error) {
+	if p == nil {
+		return "", errors.New("Algorithm can't be nil")
+	}
+	return p.Encrypt([]byte(token), CTY_JWT)
+}
+
+// DecryptToken decrypts a JWE created by EncryptToken and returns the nested JWT.
+func DecryptToken(token string, p *PBES2) (string, error) {
+	if p == nil {
+		return "", errors.New("Algorithm can't be nil")
+	}
+	plaintext, header, err := p.decrypt(token)
+	if err != nil {
+		return "", err
+	}
+	if header.Cty != CTY_JWT {
+		return "", errors.New("JWE does not contain a JWT")
+	}
+	return string(plaintext), nil
+}
+
+// EncryptKey encrypts an exported key with a password. The key may be PEM or JWK encoded.
+func EncryptKey(key []byte, p *PBES2) (string, error) {
+	if p == nil {
+		return "", errors.New("Algorithm can't be nil")
+	}
+	if len(key) == 0 {
+		return "", errors.New("Key is empty")
+	}
+	contentType := CTY_PEM
+	if bytes.HasPrefix(bytes.TrimSpace(key), []byte("{")) {
+		contentType = CTY_JWK
+	}
+	return p.Encrypt(key, contentType)
+}
+
+// DecryptKey decrypts a JWE created by EncryptKey and returns the exported key.
+func DecryptKey(token string, p *PBES2) ([]byte, error) {
+	if p == nil {
+		return nil, errors.New("Algorithm can't be nil")
+	}
+	plaintext, header, err := p.decrypt(token)
+	if err != nil {
+		return nil, err
+	}
+	if header.Cty != CTY_PEM && header.Cty != CTY_JWK {
+		return nil, errors.New("JWE does not contain a key")
+	}
+	return plaintext, nil
+}
+
+// pbkdf2 implements PBKDF2 with HMAC as pseudorandom function (RFC 8018 section 5.2).
+func pbkdf2(hash crypto.Hash, password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(hash.New, password)
+	size := prf.Size()
+	blocks := (keyLen + size - 1) / size
+	key := make([]byte, 0, blocks*size)
+	counter := make([]byte, 4)
+	u := make([]byte, size)
+	t := make([]byte, size)
+	for block := 1; block <= blocks; block++ {
+		binary.BigEndian.PutUint32(counter, uint32(block))
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(counter)
+		u = prf.Sum(u[:0])
+		copy(t, u)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for k := range t {
+				t[k] ^= u[k]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
+
+// aesKeyWrap wraps a key with AES Key Wrap (RFC 3394).
+func aesKeyWrap(kek, key []byte) ([]byte, error) {
+	if len(key)%8 != 0 || len(key) < 16 {
+		return nil, errors.New("Key to be wrapped must be a multiple of 64 bits")
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(key) / 8
+	r := make([]byte, len(key))
+	copy(r, key)
+	a := make([]byte, 8)
+	copy(a, aesKeyWrapIV)
+	b := make([]byte, 16)
+	for j := 0; j < 6; j++ {
+		for i := 0; i < n; i++ {
+			copy(b, a)
+			copy(b[8:], r[i*8:])
+			block.Encrypt(b, b)
+			t := uint64(n*j + i + 1)
+			binary.BigEndian.PutUint64(a, binary.BigEndian.Uint64(b[:8])^t)
+			copy(r[i*8:], b[8:])
+		}
+	}
+	return append(a, r...), nil
+}
+
+// aesKeyUnwrap unwraps a key wrapped with AES Key Wrap (RFC 3394).
+func aesKeyUnwrap(kek, wrapped []byte) ([]byte, error) {
+	if len(wrapped)%8 != 0 || len(wrapped) < 24 {
+		return nil, errors.New("Invalid wrapped key size")
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(wrapped)/8 - 1
+	a := make([]byte, 8)
+	copy(a, wrapped[:8])
+	r := make([]byte, n*8)
+	copy(r, wrapped[8:])
+	b := make([]byte, 16)
+	for j := 5; j >= 0; j-- {
+		for i := n - 1; i >= 0; i-- {
+			t := uint64(n*j + i + 1)
+			binary.BigEndian.PutUint64(b, binary.BigEndian.Uint64(a)^t)
+			copy(b[8:], r[i*8:])
+			block.Decrypt(b, b)
+			copy(a, b[:8])
+			copy(r[i*8:], b[8:])
+		}
+	}
+	if subtle.ConstantTimeCompare(a, aesKeyWrapIV) != 1 {
+		return nil, errors.New("JWE could not be decrypted")
+	}
+	return r, nil
+}
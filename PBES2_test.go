@@ -0,0 +1,345 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPBES2HS256A128KW(t *testing.T) {
+	alg, err := NewPBES2HS256A128KW([]byte("password"), PBES2_MIN_ITERATIONS)
+	if err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+	encrypted, err := alg.Encrypt([]byte("test"), "")
+	if err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+	decrypted, err := alg.Decrypt(encrypted)
+	if err != nil || string(decrypted) != "test" {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestPBES2HS512A256KW(t *testing.T) {
+	alg, err := NewPBES2HS512A256KW([]byte("password"), PBES2_MIN_ITERATIONS)
+	if err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+	encrypted, err := alg.Encrypt([]byte("test"), "")
+	if err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+	decrypted, err := alg.Decrypt(encrypted)
+	if err != nil || string(decrypted) != "test" {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestPBES2WrongPassword(t *testing.T) {
+	alg1, _ := NewPBES2HS256A128KW([]byte("password"), PBES2_MIN_ITERATIONS)
+	alg2, _ := NewPBES2HS256A128KW([]byte("wrong"), PBES2_MIN_ITERATIONS)
+	encrypted, _ := alg1.Encrypt([]byte("test"), "")
+	_, err := alg2.Decrypt(encrypted)
+	if err == nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestPBES2WrongAlgorithm(t *testing.T) {
+	alg1, _ := NewPBES2HS256A128KW([]byte("password"), PBES2_MIN_ITERATIONS)
+	alg2, _ := NewPBES2HS512A256KW([]byte("password"), PBES2_MIN_ITERATIONS)
+	encrypted, _ := alg1.Encrypt([]byte("test"), "")
+	_, err := alg2.Decrypt(encrypted)
+	if err == nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestPBES2WrongContentEncryption(t *testing.T) {
+	alg, _ := NewPBES2HS256A128KW([]byte("password"), PBES2_MIN_ITERATIONS)
+	salt := make([]byte, PBES2_SALT_SIZE)
+	cek := make([]byte, JWE_ENC_KEY_SIZE[JWE_A256GCM])
+	encryptedKey, _ := aesKeyWrap(alg.deriveKey(salt, PBES2_MIN_ITERATIONS), cek)
+	header := &JweHeader{
+		Alg: JWE_PBES2_HS256_A128KW,
+		Enc: JWE_A256GCM,
+		P2s: base64.RawURLEncoding.EncodeToString(salt),
+		P2c: PBES2_MIN_ITERATIONS,
+	}
+	encrypted, err := sealJWE(header, encryptedKey, cek, []byte("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = alg.Decrypt(encrypted)
+	if err == nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestPBES2InvalidParameters(t *testing.T) {
+	_, err := NewPBES2HS256A128KW(nil, PBES2_MIN_ITERATIONS)
+	if err == nil {
+		t.Log(err)
+		t.Fail()
+	}
+	_, err = NewPBES2HS256A128KW([]byte("password"), PBES2_MIN_ITERATIONS-1)
+	if err == nil {
+		t.Log(err)
+		t.Fail()
+	}
+	alg, _ := NewPBES2HS256A128KW([]byte("password"), PBES2_MIN_ITERATIONS)
+	err = alg.SetMaxIterations(1)
+	if err == nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestPBES2MaxIterations(t *testing.T) {
+	alg, _ := NewPBES2HS256A128KW([]byte("password"), 2000)
+	encrypted, _ := alg.Encrypt([]byte("test"), "")
+	err := alg.SetMaxIterations(1500)
+	if err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+	_, err = alg.Decrypt(encrypted)
+	if err == nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestPBES2HugeIterationCount(t *testing.T) {
+	alg, _ := NewPBES2HS256A128KW([]byte("password"), PBES2_MIN_ITERATIONS)
+	encrypted, _ := alg.Encrypt([]byte("test"), "")
+	parts := strings.Split(encrypted, ".")
+	header := &JweHeader{}
+	decoded, _ := base64.RawURLEncoding.DecodeString(parts[0])
+	json.Unmarshal(decoded, header)
+	header.P2c = 1 << 30
+	encoded, _ := json.Marshal(header)
+	parts[0] = base64.RawURLEncoding.EncodeToString(encoded)
+
+	start := time.Now()
+	_, err := alg.Decrypt(strings.Join(parts, "."))
+	if err == nil || time.Since(start) > time.Second {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestPBES2InvalidJWE(t *testing.T) {
+	alg, _ := NewPBES2HS256A128KW([]byte("password"), PBES2_MIN_ITERATIONS)
+	for _, token := range []string{"", "a.b.c", "....", "e30K....", "e30....", "!.a.a.a.a"} {
+		_, err := alg.Decrypt(token)
+		if err == nil {
+			t.Log(token)
+			t.Fail()
+		}
+	}
+}
+
+// TestParseJWEHeader verifies that JWE headers are decoded with the same checks as JWS headers
+// and that critical header parameters are rejected.
+func TestParseJWEHeader(t *testing.T) {
+	encode := func(header string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(header)) + "...."
+	}
+	valid := encode(`{"alg":"PBES2-HS256+A128KW","enc":"A128GCM","p2s":"AAAAAAAAAAA","p2c":1000}`)
+	if _, err := parseJWE(valid); err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+	for _, token := range []string{
+		encode(`{"alg":"PBES2-HS256+A128KW","alg":"dir","enc":"A128GCM"}`),
+		encode(`{"alg":"PBES2-HS256+A128KW","enc":"A128GCM","crit":["exp"],"exp":1}`),
+		encode(`{"alg":"PBES2-HS256+A128KW","enc":"A128GCM","x":` + strings.Repeat("[", 40) + strings.Repeat("]", 40) + `}`),
+		encode(`{"alg":1,"enc":"A128GCM"}`),
+		encode(`{"alg":"PBES2-HS256+A128KW","enc":"A128GCM","p2c":-1}`),
+		encode(`[]`),
+		// "e30" with non-zero trailing bits, which only decodes with a non-strict decoder
+		"e31....",
+	} {
+		if _, err := parseJWE(token); err == nil {
+			t.Log(token)
+			t.Fail()
+		}
+	}
+}
+
+func TestPBES2TamperedCiphertext(t *testing.T) {
+	alg, _ := NewPBES2HS256A128KW([]byte("password"), PBES2_MIN_ITERATIONS)
+	encrypted, _ := alg.Encrypt([]byte("test"), "")
+	parts := strings.Split(encrypted, ".")
+	parts[3] = base64.RawURLEncoding.EncodeToString([]byte("evil"))
+	_, err := alg.Decrypt(strings.Join(parts, "."))
+	if err == nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestEncryptToken(t *testing.T) {
+	signer, _ := NewHS256(testSecret("secret"))
+	token, _ := Create(&Claims{Expires: time.Now().Add(time.Hour).Unix()}, signer)
+	alg, _ := NewPBES2HS512A256KW([]byte("password"), PBES2_MIN_ITERATIONS)
+	encrypted, err := EncryptToken(token, alg)
+	if err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+	decrypted, err := DecryptToken(encrypted, alg)
+	if err != nil || decrypted != token {
+		t.Log(err)
+		t.Fail()
+	}
+	_, err = Parse(decrypted, signer)
+	if err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+	_, err = DecryptKey(encrypted, alg)
+	if err == nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestEncryptKey(t *testing.T) {
+	key, _ := readFixture("ecdsa_256")
+	alg, _ := NewPBES2HS256A128KW([]byte("password"), PBES2_MIN_ITERATIONS)
+	encrypted, err := EncryptKey(key, alg)
+	if err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+	decrypted, err := DecryptKey(encrypted, alg)
+	if err != nil || !bytes.Equal(key, decrypted) {
+		t.Log(err)
+		t.Fail()
+	}
+	_, err = NewES256(decrypted)
+	if err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+	_, err = DecryptToken(encrypted, alg)
+	if err == nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestEncryptNilAlgorithm(t *testing.T) {
+	_, err := EncryptToken("token", nil)
+	if err == nil {
+		t.Log(err)
+		t.Fail()
+	}
+	_, err = EncryptKey([]byte("key"), nil)
+	if err == nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+// TestPBES2KnownAnswer checks the key derivation and key wrapping of RFC 7517 appendix C.3 and
+// C.4. The content of the example is encrypted with A128CBC-HS256, which is not supported.
+func TestPBES2KnownAnswer(t *testing.T) {
+	alg, _ := NewPBES2HS256A128KW([]byte("Thus from my lips, by yours, my sin is purged."), 4096)
+	salt, _ := base64.RawURLEncoding.DecodeString("2WCTcJZ1Rvd_CJuJripQ1w")
+	cek := []byte{111, 27, 25, 52, 66, 29, 20, 78, 92, 176, 56, 240, 65, 208, 82, 112,
+		161, 131, 36, 55, 202, 236, 185, 172, 129, 23, 153, 194, 195, 48, 253, 182}
+	want := []byte{110, 171, 169, 92, 129, 92, 109, 117, 233, 242, 116, 233, 170, 14, 24, 75}
+	kek := alg.deriveKey(salt, 4096)
+	if !bytes.Equal(want, kek) {
+		t.Log(kek)
+		t.Fail()
+	}
+	encryptedKey, err := aesKeyWrap(kek, cek)
+	if err != nil || base64.RawURLEncoding.EncodeToString(encryptedKey) != "TrqXOwuNUfDV9VPTNbyGvEJ9JMjefAVn-TR1uIxR9p6hsRQh9Tk7BA" {
+		t.Log(base64.RawURLEncoding.EncodeToString(encryptedKey), err)
+		t.Fail()
+	}
+	unwrapped, err := aesKeyUnwrap(kek, encryptedKey)
+	if err != nil || !bytes.Equal(cek, unwrapped) {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestPBKDF2(t *testing.T) {
+	want, _ := hex.DecodeString("120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b")
+	have := pbkdf2(crypto.SHA256, []byte("password"), []byte("salt"), 1, 32)
+	if !bytes.Equal(want, have) {
+		t.Log(hex.EncodeToString(have))
+		t.Fail()
+	}
+	want, _ = hex.DecodeString("c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a")
+	have = pbkdf2(crypto.SHA256, []byte("password"), []byte("salt"), 4096, 32)
+	if !bytes.Equal(want, have) {
+		t.Log(hex.EncodeToString(have))
+		t.Fail()
+	}
+}
+
+func TestAESKeyWrap(t *testing.T) {
+	kek, _ := hex.DecodeString("000102030405060708090A0B0C0D0E0F")
+	key, _ := hex.DecodeString("00112233445566778899AABBCCDDEEFF")
+	want, _ := hex.DecodeString("1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5")
+	wrapped, err := aesKeyWrap(kek, key)
+	if err != nil || !bytes.Equal(want, wrapped) {
+		t.Log(err)
+		t.Fail()
+	}
+	unwrapped, err := aesKeyUnwrap(kek, wrapped)
+	if err != nil || !bytes.Equal(key, unwrapped) {
+		t.Log(err)
+		t.Fail()
+	}
+	wrapped[0] ^= 1
+	_, err = aesKeyUnwrap(kek, wrapped)
+	if err == nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
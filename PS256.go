@@ -0,0 +1,55 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import "crypto"
+
+// PS256 provides methods for signing and verifying JWTs with RSASSA-PSS using SHA-256.
+type PS256 struct {
+	rsa *_rsapss
+}
+
+// NewPS256 creates a new PS256 helper from a RSA private key. The private key must be PEM encoded.
+func NewPS256(key []byte) (*PS256, error) {
+	rsa, err := newRSAPSS(JWT_PS256, key, crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	return &PS256{rsa}, nil
+}
+
+// Sign signs arbitrary data and returns a signature
+func (e *PS256) Sign(data []byte) ([]byte, error) {
+	return e.rsa.sign(data)
+}
+
+// Verify verifies signed data
+func (e *PS256) Verify(data []byte, signature []byte) error {
+	return e.rsa.verify(data, signature)
+}
+
+// Name returns the the JWT algorithm name
+func (e *PS256) Name() string {
+	return e.rsa.name
+}
@@ -55,21 +55,23 @@ func newRSA(name string, key []byte, hash crypto.Hash) (*_rsa, error) {
 	if err != nil {
 		return nil, err
 	}
-	publicKey := privateKey.PublicKey
+	return newRSAFromKey(name, privateKey, hash), nil
+}
 
-	return &_rsa{privateKey, &publicKey, hash, name}, nil
+func newRSAFromKey(name string, privateKey *rsa.PrivateKey, hash crypto.Hash) *_rsa {
+	publicKey := privateKey.PublicKey
+	return &_rsa{privateKey, &publicKey, hash, name}
 }
 
 func (e *_rsa) sign(data []byte) ([]byte, error) {
-	hasher := e.hash.New()
-	hasher.Write(data)
-	hash := hasher.Sum(nil)
+	if e.privateKey == nil {
+		return nil, ErrVerifyOnly
+	}
+	hash := digest(e.hash, data)
 	return rsa.SignPKCS1v15(rand.Reader, e.privateKey, e.hash, hash)
 }
 
 func (e *_rsa) verify(data []byte, signature []byte) error {
-	hasher := e.hash.New()
-	hasher.Write(data)
-	hash := hasher.Sum(nil)
+	hash := digest(e.hash, data)
 	return rsa.VerifyPKCS1v15(e.publicKey, e.hash, hash, signature)
 }
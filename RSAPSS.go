@@ -55,22 +55,30 @@ func newRSAPSS(name string, key []byte, hash crypto.Hash) (*_rsapss, error) {
 	if err != nil {
 		return nil, err
 	}
+	return newRSAPSSFromKey(name, privateKey, hash), nil
+}
+
+func newRSAPSSFromKey(name string, privateKey *rsa.PrivateKey, hash crypto.Hash) *_rsapss {
 	publicKey := &privateKey.PublicKey
+	return &_rsapss{privateKey, publicKey, hash, name, pssOptions(hash)}
+}
 
-	options := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto, Hash: hash}
-	return &_rsapss{privateKey, publicKey, hash, name, options}, nil
+// pssOptions returns the signing options of RFC 7518 section 3.5: the salt is as long as the hash.
+func pssOptions(hash crypto.Hash) *rsa.PSSOptions {
+	return &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: hash}
 }
 
 func (e *_rsapss) sign(data []byte) ([]byte, error) {
-	hasher := e.hash.New()
-	hasher.Write(data)
-	hash := hasher.Sum(nil)
+	if e.privateKey == nil {
+		return nil, ErrVerifyOnly
+	}
+	hash := digest(e.hash, data)
 	return rsa.SignPSS(rand.Reader, e.privateKey, e.hash, hash, e.options)
 }
 
+// verify accepts any salt length, so tokens signed by earlier versions with the longest
+// possible salt still verify.
 func (e *_rsapss) verify(data []byte, signature []byte) error {
-	hasher := e.hash.New()
-	hasher.Write(data)
-	hash := hasher.Sum(nil)
-	return rsa.VerifyPSS(e.publicKey, e.hash, hash, signature, e.options)
+	hash := digest(e.hash, data)
+	return rsa.VerifyPSS(e.publicKey, e.hash, hash, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto, Hash: e.hash})
 }
@@ -25,6 +25,7 @@ package jwt
 
 import (
 	"crypto"
+	"crypto/rsa"
 	"testing"
 )
 
@@ -81,3 +82,20 @@ func TestRSAPSSInvalidPrivateKey(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestRSAPSSSaltLength(t *testing.T) {
+	for name, hash := range map[string]crypto.Hash{JWT_PS256: crypto.SHA256, JWT_PS348: crypto.SHA384, JWT_PS512: crypto.SHA512} {
+		key, _ := GenerateKey(name)
+		signature, err := key.Algorithm.Sign([]byte("data"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		privateKey, _ := key.PrivateJWK.Key()
+		publicKey := &privateKey.(*rsa.PrivateKey).PublicKey
+		options := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: hash}
+		if err := rsa.VerifyPSS(publicKey, hash, digest(hash, []byte("data")), signature, options); err != nil {
+			t.Log(name, err)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,223 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// TYP_AT_JWT is the "typ" header of JWT access tokens (RFC 9068 section 2.1).
+const TYP_AT_JWT = "at+jwt"
+
+// AccessToken holds the claims of a JWT access token (RFC 9068 section 2.2). Claims are
+// read from and written to the embedded standard claims.
+type AccessToken struct {
+	Claims
+	// ClientID is the "client_id" claim.
+	ClientID string
+	// ID is the "jti" claim.
+	ID string
+	// Scopes are the scopes of the space delimited "scope" claim.
+	Scopes []string
+	// AuthTime is the "auth_time" claim.
+	AuthTime int64
+	// ACR is the "acr" claim.
+	ACR string
+	// AMR is the "amr" claim.
+	AMR []string
+	// Roles, Groups and Entitlements are the SCIM attributes of RFC 9068 section 2.2.3.1.
+	Roles        []string
+	Groups       []string
+	Entitlements []string
+}
+
+// CreateAccessToken creates a JWT access token with the "typ" header "at+jwt". A random
+// "jti" is generated if ID is empty. The "iss", "exp", "aud", "sub" and "client_id" claims
+// are required.
+func CreateAccessToken(token *AccessToken, algorithm Algorithm, opts ...Option) (string, error) {
+	if token == nil {
+		return "", errors.New("Access token can't be nil")
+	}
+	claims := token.claims()
+	if token.ID == "" {
+		id, err := newTokenID()
+		if err != nil {
+			return "", err
+		}
+		claims.Raw["jti"] = id
+	}
+	if err := requireClaims("iss", "exp", "aud", "sub")(&JwtToken{Claims: claims}); err != nil {
+		return "", err
+	}
+	if token.ClientID == "" {
+		return "", &MissingClaimError{"client_id"}
+	}
+	return Create(&claims, algorithm, append(opts, WithType(TYP_AT_JWT))...)
+}
+
+// MarshalJSON encodes the access token claims together with the standard claims.
+func (t AccessToken) MarshalJSON() ([]byte, error) {
+	return t.claims().MarshalJSON()
+}
+
+// UnmarshalJSON decodes the standard claims and the access token claims.
+func (t *AccessToken) UnmarshalJSON(data []byte) error {
+	var claims Claims
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return err
+	}
+	token, err := newAccessToken(&claims)
+	if err != nil {
+		return err
+	}
+	*t = *token
+	return nil
+}
+
+// claims returns the standard claims with the access token claims added to a copy of Raw.
+func (t AccessToken) claims() Claims {
+	claims := t.Claims
+	claims.Raw = map[string]interface{}{}
+	for name, value := range t.Raw {
+		claims.Raw[name] = value
+	}
+	if t.ClientID != "" {
+		claims.Raw["client_id"] = t.ClientID
+	}
+	if t.ID != "" {
+		claims.Raw["jti"] = t.ID
+	}
+	if len(t.Scopes) > 0 {
+		claims.Raw["scope"] = strings.Join(t.Scopes, " ")
+	}
+	if t.AuthTime != 0 {
+		claims.Raw["auth_time"] = t.AuthTime
+	}
+	if t.ACR != "" {
+		claims.Raw["acr"] = t.ACR
+	}
+	for name, values := range map[string][]string{
+		"amr":          t.AMR,
+		"roles":        t.Roles,
+		"groups":       t.Groups,
+		"entitlements": t.Entitlements,
+	} {
+		if len(values) > 0 {
+			claims.Raw[name] = values
+		}
+	}
+	return claims
+}
+
+// AccessTokenVerifier verifies JWT access tokens (RFC 9068 section 4). Tokens must have the
+// "typ" header "at+jwt", so ID tokens are not accepted as access tokens.
+type AccessTokenVerifier struct {
+	// Issuer is the expected "iss" claim.
+	Issuer string
+	// Audience is the identifier of the resource server, which must be one of the audiences.
+	Audience string
+	// Algorithm verifies the token signature.
+	Algorithm Algorithm
+	// KeySet verifies the token signature with the key matching the "kid" header. It takes
+	// precedence over Algorithm.
+	KeySet *KeySet
+	// Options are applied when parsing the token.
+	Options []Option
+}
+
+// NewAccessTokenVerifier creates a new access token verifier.
+func NewAccessTokenVerifier(issuer, audience string, algorithm Algorithm) *AccessTokenVerifier {
+	return &AccessTokenVerifier{Issuer: issuer, Audience: audience, Algorithm: algorithm}
+}
+
+// Verify parses and validates an access token. The validators are run after the checks of
+// the verifier, e.g. Require for scopes and roles.
+func (v *AccessTokenVerifier) Verify(raw string, validators ...Validator) (*AccessToken, error) {
+	if v.Issuer == "" || v.Audience == "" {
+		return nil, errors.New("Issuer and audience can't be empty")
+	}
+	checks := []Validator{
+		ValidateType(TYP_AT_JWT),
+		requireClaims("iss", "exp", "aud", "sub", "client_id", "iat", "jti"),
+		ValidateIssuer(v.Issuer),
+		ValidateAudience(v.Audience),
+	}
+	token, err := parseAndValidate(raw, v.Algorithm, v.KeySet, append(checks, validators...), v.Options)
+	if err != nil {
+		return nil, err
+	}
+	return newAccessToken(&token.Claims)
+}
+
+// newAccessToken reads the access token claims from the standard claims.
+func newAccessToken(claims *Claims) (*AccessToken, error) {
+	token := &AccessToken{Claims: *claims}
+	var err error
+	if token.ClientID, err = stringMember(claims.Raw, "client_id"); err != nil {
+		return nil, err
+	}
+	if token.ID, err = stringMember(claims.Raw, "jti"); err != nil {
+		return nil, err
+	}
+	scope, err := stringMember(claims.Raw, "scope")
+	if err != nil {
+		return nil, err
+	}
+	token.Scopes = strings.Fields(scope)
+	if token.AuthTime, err = intMember(claims.Raw, "auth_time"); err != nil {
+		return nil, err
+	}
+	if token.ACR, err = stringMember(claims.Raw, "acr"); err != nil {
+		return nil, err
+	}
+	if token.AMR, err = stringsMember(claims.Raw, "amr"); err != nil {
+		return nil, err
+	}
+	for name, values := range map[string]*[]string{
+		"roles":        &token.Roles,
+		"groups":       &token.Groups,
+		"entitlements": &token.Entitlements,
+	} {
+		switch value := claims.Raw[name].(type) {
+		case string:
+			*values = []string{value}
+		case []interface{}:
+			*values = stringValues(value)
+		}
+	}
+	return token, nil
+}
+
+// newTokenID returns a random token identifier for the "jti" claim.
+func newTokenID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(id), nil
+}
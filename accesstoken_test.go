@@ -0,0 +1,158 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+const (
+	atIssuer   = "https://as.example.com"
+	atResource = "https://rs.example.com"
+)
+
+func testAccessToken() *AccessToken {
+	return &AccessToken{
+		Claims: Claims{
+			Issuer:   atIssuer,
+			Subject:  "user",
+			Audience: atResource,
+			Expires:  time.Now().Add(time.Hour).Unix(),
+		},
+		ClientID:     "client",
+		Scopes:       []string{"read", "write"},
+		AuthTime:     time.Now().Add(-time.Minute).Unix(),
+		ACR:          "urn:mace:incommon:iap:silver",
+		AMR:          []string{"pwd", "otp"},
+		Roles:        []string{"admin"},
+		Groups:       []string{"staff"},
+		Entitlements: []string{"premium"},
+	}
+}
+
+func TestAccessToken(t *testing.T) {
+	rs256, _ := NewRS256(mustReadFixture("rsa"))
+	raw, err := CreateAccessToken(testAccessToken(), rs256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unverified, _ := Decode(raw)
+	if unverified.Header.Typ != TYP_AT_JWT {
+		t.Log(unverified.Header.Typ)
+		t.Fail()
+	}
+	token, err := NewAccessTokenVerifier(atIssuer, atResource, rs256).Verify(raw, Require(Scope("read")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.ClientID != "client" || token.ID == "" || token.Subject != "user" || token.ACR != "urn:mace:incommon:iap:silver" {
+		t.Log(token)
+		t.Fail()
+	}
+	if len(token.Scopes) != 2 || len(token.AMR) != 2 || token.AuthTime == 0 {
+		t.Log(token)
+		t.Fail()
+	}
+	if len(token.Roles) != 1 || token.Groups[0] != "staff" || token.Entitlements[0] != "premium" {
+		t.Log(token)
+		t.Fail()
+	}
+	_, err = NewAccessTokenVerifier(atIssuer, atResource, rs256).Verify(raw, Require(Scope("delete")))
+	if _, ok := err.(*InsufficientScopeError); !ok {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestAccessTokenJSON(t *testing.T) {
+	token := testAccessToken()
+	token.ID = "id"
+	data, err := json.Marshal(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &AccessToken{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.ClientID != "client" || decoded.ID != "id" || decoded.Subject != "user" || len(decoded.Scopes) != 2 || decoded.Roles[0] != "admin" {
+		t.Log(string(data))
+		t.Fail()
+	}
+}
+
+func TestAccessTokenRejectsIDToken(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	claims := testAccessToken().Claims
+	claims.Raw = map[string]interface{}{"client_id": "client", "jti": "id"}
+	for _, typ := range []string{"", "JWT", "dpop+jwt"} {
+		opts := []Option{}
+		if typ != "" {
+			opts = append(opts, WithType(typ))
+		}
+		raw, _ := Create(&claims, hs256, opts...)
+		if _, err := NewAccessTokenVerifier(atIssuer, atResource, hs256).Verify(raw); err != ErrInvalidType {
+			t.Log(typ, err)
+			t.Fail()
+		}
+	}
+	raw, _ := Create(&claims, hs256, WithType("application/AT+JWT"))
+	if _, err := NewAccessTokenVerifier(atIssuer, atResource, hs256).Verify(raw); err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestAccessTokenRequiredClaims(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	for claim, modify := range map[string]func(token *AccessToken){
+		"iss":       func(token *AccessToken) { token.Issuer = "" },
+		"sub":       func(token *AccessToken) { token.Subject = "" },
+		"aud":       func(token *AccessToken) { token.Audience = "" },
+		"exp":       func(token *AccessToken) { token.Expires = 0 },
+		"client_id": func(token *AccessToken) { token.ClientID = "" },
+	} {
+		token := testAccessToken()
+		modify(token)
+		_, err := CreateAccessToken(token, hs256)
+		if missing, ok := err.(*MissingClaimError); !ok || missing.Claim != claim {
+			t.Log(claim, err)
+			t.Fail()
+		}
+	}
+	claims := testAccessToken().Claims
+	claims.Raw = map[string]interface{}{"client_id": "client"}
+	raw, _ := Create(&claims, hs256, WithType(TYP_AT_JWT))
+	_, err := NewAccessTokenVerifier(atIssuer, atResource, hs256).Verify(raw)
+	if missing, ok := err.(*MissingClaimError); !ok || missing.Claim != "jti" {
+		t.Log(err)
+		t.Fail()
+	}
+	raw, _ = CreateAccessToken(testAccessToken(), hs256)
+	if _, err := NewAccessTokenVerifier(atIssuer, "https://other.example.com", hs256).Verify(raw); err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,167 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+)
+
+var ErrVerifyOnly = errors.New("Public keys can't be used for signing")
+
+// signingHashes maps the algorithm names to their hash functions.
+var signingHashes = map[string]crypto.Hash{
+	JWT_HS256: crypto.SHA256, JWT_HS348: crypto.SHA384, JWT_HS512: crypto.SHA512,
+	JWT_RS256: crypto.SHA256, JWT_RS384: crypto.SHA384, JWT_RS512: crypto.SHA512,
+	JWT_PS256: crypto.SHA256, JWT_PS348: crypto.SHA384, JWT_PS512: crypto.SHA512,
+	JWT_ES256: crypto.SHA256, JWT_ES348: crypto.SHA384, JWT_ES512: crypto.SHA512,
+}
+
+// NewAlgorithm creates an algorithm from a parsed key. Supported keys are []byte for HMAC
+// secrets, *rsa.PrivateKey, *ecdsa.PrivateKey and ed25519.PrivateKey for signing and
+// verifying, and *rsa.PublicKey, *ecdsa.PublicKey and ed25519.PublicKey for verifying only.
+// Algorithms created from public keys return ErrVerifyOnly from Sign.
+func NewAlgorithm(name string, key interface{}) (Algorithm, error) {
+	hash := signingHashes[name]
+	switch name {
+	case JWT_HS256, JWT_HS348, JWT_HS512:
+		secret, ok := key.([]byte)
+		if !ok {
+			return nil, errors.New("HMAC secret must be a byte slice")
+		}
+		hmac, err := newHMAC(name, secret, hash)
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case JWT_HS256:
+			return &HS256{hmac}, nil
+		case JWT_HS348:
+			return &HS384{hmac}, nil
+		}
+		return &HS512{hmac}, nil
+	case JWT_RS256, JWT_RS384, JWT_RS512, JWT_PS256, JWT_PS348, JWT_PS512:
+		var privateKey *rsa.PrivateKey
+		var publicKey *rsa.PublicKey
+		switch k := key.(type) {
+		case *rsa.PrivateKey:
+			if k == nil {
+				return nil, errors.New("Key is empty")
+			}
+			privateKey, publicKey = k, &k.PublicKey
+		case *rsa.PublicKey:
+			publicKey = k
+		default:
+			return nil, errors.New("Key is not a RSA key")
+		}
+		if publicKey == nil || publicKey.N == nil || publicKey.E < 2 {
+			return nil, errors.New("Invalid RSA key")
+		}
+		if privateKey != nil && privateKey.D == nil {
+			return nil, errors.New("Invalid RSA private key")
+		}
+		options := pssOptions(hash)
+		switch name {
+		case JWT_RS256:
+			return &RS256{&_rsa{privateKey, publicKey, hash, name}}, nil
+		case JWT_RS384:
+			return &RS384{&_rsa{privateKey, publicKey, hash, name}}, nil
+		case JWT_RS512:
+			return &RS512{&_rsa{privateKey, publicKey, hash, name}}, nil
+		case JWT_PS256:
+			return &PS256{&_rsapss{privateKey, publicKey, hash, name, options}}, nil
+		case JWT_PS348:
+			return &PS384{&_rsapss{privateKey, publicKey, hash, name, options}}, nil
+		}
+		return &PS512{&_rsapss{privateKey, publicKey, hash, name, options}}, nil
+	case JWT_ES256, JWT_ES348, JWT_ES512:
+		var e *_ecdsa
+		var err error
+		switch k := key.(type) {
+		case *ecdsa.PrivateKey:
+			e, err = newECDSAFromKey(name, k, hash)
+		case *ecdsa.PublicKey:
+			e, err = newECDSAFromPublicKey(name, k, hash)
+		default:
+			return nil, errors.New("Key is not an ECDSA key")
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case JWT_ES256:
+			return &ES256{e}, nil
+		case JWT_ES348:
+			return &ES384{e}, nil
+		}
+		return &ES512{e}, nil
+	case JWT_EdDSA:
+		switch k := key.(type) {
+		case ed25519.PrivateKey:
+			if len(k) != ed25519.PrivateKeySize {
+				return nil, errors.New("Invalid Ed25519 private key")
+			}
+			return newEdDSAFromKey(k), nil
+		case ed25519.PublicKey:
+			if len(k) != ed25519.PublicKeySize {
+				return nil, errors.New("Invalid Ed25519 public key")
+			}
+			return &EdDSA{nil, k}, nil
+		}
+		return nil, errors.New("Key is not an Ed25519 key")
+	}
+	return nil, errors.New("Unsupported JWT algorithm: " + name)
+}
+
+// NewVerifierFromPEM creates an algorithm which only verifies signatures from a PEM encoded
+// public key, e.g. GeneratedKey.PublicKeyPEM. PKIX ("PUBLIC KEY" and "EC PUBLIC KEY") and
+// PKCS #1 ("RSA PUBLIC KEY") encodings are supported.
+func NewVerifierFromPEM(name string, publicKeyPEM []byte) (Algorithm, error) {
+	block, rest := pem.Decode(publicKeyPEM)
+	if block == nil {
+		return nil, errors.New("Could not parse public key from PEM file")
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("Multiple blocks per key are not supported")
+	}
+	var publicKey interface{}
+	var err error
+	switch block.Type {
+	case "PUBLIC KEY", "EC PUBLIC KEY":
+		publicKey, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		publicKey, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, errors.New("PEM block is not a public key: " + block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewAlgorithm(name, publicKey)
+}
@@ -0,0 +1,72 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"math/big"
+	"testing"
+)
+
+func TestNewAlgorithmInvalidKeys(t *testing.T) {
+	var nilRSA *rsa.PrivateKey
+	var nilRSAPublic *rsa.PublicKey
+	var nilECDSA *ecdsa.PrivateKey
+	var nilECDSAPublic *ecdsa.PublicKey
+	offCurve := &ecdsa.PublicKey{Curve: elliptic.P256(), X: big.NewInt(1), Y: big.NewInt(1)}
+	for _, test := range []struct {
+		names []string
+		key   interface{}
+	}{
+		{[]string{JWT_HS256, JWT_HS348, JWT_HS512}, nil},
+		{[]string{JWT_HS256, JWT_HS348, JWT_HS512}, []byte(nil)},
+		{[]string{JWT_HS256, JWT_HS348, JWT_HS512}, "secret"},
+		{[]string{JWT_RS256, JWT_PS256}, nil},
+		{[]string{JWT_RS256, JWT_PS256}, nilRSA},
+		{[]string{JWT_RS256, JWT_PS256}, nilRSAPublic},
+		{[]string{JWT_RS256, JWT_PS256}, &rsa.PrivateKey{}},
+		{[]string{JWT_RS256, JWT_PS256}, &rsa.PublicKey{}},
+		{[]string{JWT_RS256, JWT_PS256}, &rsa.PublicKey{N: big.NewInt(15), E: 1}},
+		{[]string{JWT_ES256, JWT_ES348, JWT_ES512}, nil},
+		{[]string{JWT_ES256, JWT_ES348, JWT_ES512}, nilECDSA},
+		{[]string{JWT_ES256, JWT_ES348, JWT_ES512}, nilECDSAPublic},
+		{[]string{JWT_ES256, JWT_ES348, JWT_ES512}, &ecdsa.PrivateKey{}},
+		{[]string{JWT_ES256, JWT_ES348, JWT_ES512}, &ecdsa.PublicKey{}},
+		{[]string{JWT_ES256}, offCurve},
+		{[]string{JWT_EdDSA}, nil},
+		{[]string{JWT_EdDSA}, ed25519.PrivateKey(nil)},
+		{[]string{JWT_EdDSA}, ed25519.PrivateKey{1, 2}},
+		{[]string{JWT_EdDSA}, ed25519.PublicKey{1, 2}},
+	} {
+		for _, name := range test.names {
+			if _, err := NewAlgorithm(name, test.key); err == nil {
+				t.Logf("%s %#v", name, test.key)
+				t.Fail()
+			}
+		}
+	}
+}
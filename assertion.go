@@ -0,0 +1,241 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// CLIENT_ASSERTION_TYPE is the "client_assertion_type" of JWT client assertions (RFC 7523 section 2.2).
+	CLIENT_ASSERTION_TYPE = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+	// JWT_BEARER_GRANT_TYPE is the "grant_type" of JWT authorization grants (RFC 7523 section 2.1).
+	JWT_BEARER_GRANT_TYPE             = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+	CLIENT_ASSERTION_DEFAULT_LIFETIME = time.Minute
+	ASSERTION_DEFAULT_MAX_LIFETIME    = time.Hour
+)
+
+var (
+	ErrAssertionReplay            = errors.New("Assertion has already been used")
+	ErrAssertionLifetime          = errors.New("Assertion lifetime is too long")
+	ErrInvalidClientAssertionType = errors.New("Invalid client assertion type")
+	ErrInvalidClientID            = errors.New("Client ID does not match the assertion")
+)
+
+// CreateClientAssertion creates a client assertion for the private_key_jwt or, with an HMAC
+// algorithm keyed with the client secret, the client_secret_jwt authentication method
+// (OpenID Connect Core 1.0 section 9). Issuer and subject are the client ID, the audience is
+// the token endpoint. The assertion expires after CLIENT_ASSERTION_DEFAULT_LIFETIME and has
+// a random "jti".
+func CreateClientAssertion(clientID, tokenEndpoint string, algorithm Algorithm, opts ...Option) (string, error) {
+	if clientID == "" || tokenEndpoint == "" {
+		return "", errors.New("Client ID and token endpoint can't be empty")
+	}
+	id, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+	return Create(&Claims{
+		Issuer:   clientID,
+		Subject:  clientID,
+		Audience: tokenEndpoint,
+		Expires:  time.Now().Add(CLIENT_ASSERTION_DEFAULT_LIFETIME).Unix(),
+		Raw:      map[string]interface{}{"jti": id},
+	}, algorithm, opts...)
+}
+
+// ClientAssertionParams returns the form parameters authenticating a token request with a
+// client assertion.
+func ClientAssertionParams(clientID, assertion string) url.Values {
+	return url.Values{
+		"client_id":             {clientID},
+		"client_assertion_type": {CLIENT_ASSERTION_TYPE},
+		"client_assertion":      {assertion},
+	}
+}
+
+// CreateAuthorizationGrant creates a JWT used as authorization grant (RFC 7523 section 2.1).
+// The "iss", "sub", "aud" and "exp" claims are required, a random "jti" is added if missing.
+func CreateAuthorizationGrant(claims *Claims, algorithm Algorithm, opts ...Option) (string, error) {
+	if claims == nil {
+		return "", errors.New("Claims can't be nil")
+	}
+	if err := requireClaims("iss", "sub", "aud", "exp")(&JwtToken{Claims: *claims}); err != nil {
+		return "", err
+	}
+	grant := *claims
+	grant.Raw = map[string]interface{}{}
+	for name, value := range claims.Raw {
+		grant.Raw[name] = value
+	}
+	if _, ok := grant.Raw["jti"]; !ok {
+		id, err := newTokenID()
+		if err != nil {
+			return "", err
+		}
+		grant.Raw["jti"] = id
+	}
+	return Create(&grant, algorithm, opts...)
+}
+
+// AuthorizationGrantParams returns the form parameters of a token request with a JWT
+// authorization grant.
+func AuthorizationGrantParams(assertion string) url.Values {
+	return url.Values{
+		"grant_type": {JWT_BEARER_GRANT_TYPE},
+		"assertion":  {assertion},
+	}
+}
+
+// AssertionVerifier verifies client assertions and authorization grants at the token
+// endpoint (RFC 7523 section 3). Assertions are only accepted once.
+type AssertionVerifier struct {
+	// Audiences are the identifiers of the authorization server, usually the token endpoint
+	// URL and the issuer identifier. The "aud" claim must contain one of them.
+	Audiences []string
+	// Keys returns the keys of an issuer, which is the client ID for client assertions. A
+	// client_secret_jwt client has a key set with an HMAC key of its secret.
+	Keys func(issuer string) (*KeySet, error)
+	// MaxLifetime is the maximum time until an assertion expires. ASSERTION_DEFAULT_MAX_LIFETIME
+	// is used if zero.
+	MaxLifetime time.Duration
+	// Replay detects assertions which have been used before. Replays are not detected if nil.
+	Replay ReplayCache
+	// Options are applied when parsing an assertion.
+	Options []Option
+}
+
+// NewAssertionVerifier creates a verifier with an in-memory replay cache.
+func NewAssertionVerifier(keys func(issuer string) (*KeySet, error), audiences ...string) *AssertionVerifier {
+	return &AssertionVerifier{Audiences: audiences, Keys: keys, Replay: NewMemoryReplayCache()}
+}
+
+// VerifyClientAssertion verifies a client assertion. Issuer and subject must be the client ID.
+func (v *AssertionVerifier) VerifyClientAssertion(assertion string, validators ...Validator) (*JwtToken, error) {
+	checks := []Validator{func(token *JwtToken) error {
+		if token.Claims.Subject != token.Claims.Issuer {
+			return errors.New("Subject of a client assertion must be the client ID")
+		}
+		return nil
+	}}
+	return v.verify(assertion, append(checks, validators...))
+}
+
+// VerifyAuthorizationGrant verifies a JWT authorization grant. The subject is the resource
+// owner the access token is requested for.
+func (v *AssertionVerifier) VerifyAuthorizationGrant(assertion string, validators ...Validator) (*JwtToken, error) {
+	return v.verify(assertion, validators)
+}
+
+// VerifyRequest verifies the client assertion of a token request and returns the client ID.
+func (v *AssertionVerifier) VerifyRequest(r *http.Request) (string, *JwtToken, error) {
+	if err := r.ParseForm(); err != nil {
+		return "", nil, err
+	}
+	if r.PostForm.Get("client_assertion_type") != CLIENT_ASSERTION_TYPE {
+		return "", nil, ErrInvalidClientAssertionType
+	}
+	token, err := v.VerifyClientAssertion(r.PostForm.Get("client_assertion"))
+	if err != nil {
+		return "", nil, err
+	}
+	clientID := r.PostForm.Get("client_id")
+	if clientID != "" && clientID != token.Claims.Issuer {
+		return "", nil, ErrInvalidClientID
+	}
+	return token.Claims.Issuer, token, nil
+}
+
+func (v *AssertionVerifier) verify(assertion string, validators []Validator) (*JwtToken, error) {
+	if v.Keys == nil || len(v.Audiences) == 0 {
+		return nil, errors.New("Keys and audiences can't be empty")
+	}
+	unverified, err := Decode(assertion, v.Options...)
+	if err != nil {
+		return nil, err
+	}
+	if unverified.Claims.Issuer == "" {
+		return nil, &MissingClaimError{"iss"}
+	}
+	keys, err := v.Keys(unverified.Claims.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	if keys == nil {
+		return nil, ErrKeyNotFound
+	}
+	maxLifetime := v.MaxLifetime
+	if maxLifetime == 0 {
+		maxLifetime = ASSERTION_DEFAULT_MAX_LIFETIME
+	}
+	checks := []Validator{
+		requireClaims("iss", "sub", "aud", "exp", "jti"),
+		// the replay key is built from jti, so it must be a non-empty string
+		func(token *JwtToken) error {
+			id, err := stringMember(token.Claims.Raw, "jti")
+			if err != nil {
+				return err
+			}
+			if id == "" {
+				return &MissingClaimError{"jti"}
+			}
+			return nil
+		},
+		func(token *JwtToken) error {
+			for _, audience := range v.Audiences {
+				if contains(token.Claims.Audiences(), audience) {
+					return nil
+				}
+			}
+			return errors.New("Invalid audience")
+		},
+		func(token *JwtToken) error {
+			if time.Unix(token.Claims.Expires, 0).After(time.Now().Add(maxLifetime)) {
+				return ErrAssertionLifetime
+			}
+			return nil
+		},
+	}
+	token, err := parseAndValidate(assertion, nil, keys, append(checks, validators...), v.Options)
+	if err != nil {
+		return nil, err
+	}
+	if v.Replay != nil {
+		id, _ := token.Claims.Raw["jti"].(string)
+		if v.Replay.Seen(replayKey(token.Claims.Issuer, id), time.Unix(token.Claims.Expires, 0)) {
+			return nil, ErrAssertionReplay
+		}
+	}
+	return token, nil
+}
+
+// replayKey identifies an assertion by issuer and "jti". The issuer is length prefixed, so
+// no two issuer and identifier pairs share a key.
+func replayKey(issuer, id string) string {
+	return strconv.Itoa(len(issuer)) + ":" + issuer + id
+}
@@ -0,0 +1,185 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const tokenEndpoint = "https://as.example.com/token"
+
+func testAssertionVerifier(t *testing.T) (*AssertionVerifier, Algorithm, Algorithm) {
+	rs256, _ := NewRS256(mustReadFixture("rsa"))
+	hs256, _ := NewHS256(testSecret("client secret"))
+	rsaKey, _ := NewKey("client1", rs256)
+	secretKey, _ := NewKey("secret", hs256)
+	clients := map[string]*KeySet{
+		"client1": NewKeySet(rsaKey),
+		"client2": NewKeySet(secretKey),
+	}
+	verifier := NewAssertionVerifier(func(issuer string) (*KeySet, error) {
+		return clients[issuer], nil
+	}, tokenEndpoint, atIssuer)
+	return verifier, rs256, hs256
+}
+
+func TestClientAssertion(t *testing.T) {
+	verifier, rs256, hs256 := testAssertionVerifier(t)
+	assertion, err := CreateClientAssertion("client1", tokenEndpoint, rs256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := verifier.VerifyClientAssertion(assertion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Claims.Subject != "client1" || token.Claims.Raw["jti"] == "" || token.Claims.Expires > time.Now().Add(time.Minute).Unix() {
+		t.Log(token.Claims)
+		t.Fail()
+	}
+	if _, err := verifier.VerifyClientAssertion(assertion); err != ErrAssertionReplay {
+		t.Log(err)
+		t.Fail()
+	}
+
+	assertion, _ = CreateClientAssertion("client2", tokenEndpoint, hs256)
+	if _, err := verifier.VerifyClientAssertion(assertion); err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+	// client2 must not be able to authenticate as client1
+	assertion, _ = CreateClientAssertion("client1", tokenEndpoint, hs256)
+	if _, err := verifier.VerifyClientAssertion(assertion); err != ErrKeyNotFound {
+		t.Log(err)
+		t.Fail()
+	}
+	assertion, _ = CreateClientAssertion("client3", tokenEndpoint, rs256)
+	if _, err := verifier.VerifyClientAssertion(assertion); err != ErrKeyNotFound {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+// TestAssertionReplayKey verifies that assertions of different issuers never share a replay key.
+func TestAssertionReplayKey(t *testing.T) {
+	cache := NewMemoryReplayCache()
+	expires := time.Now().Add(time.Minute)
+	for _, pair := range [][2]string{{"a.b", "c"}, {"a", "b.c"}, {"a.b.c", ""}, {"", "a.b.c"}, {"a\x00", "b"}, {"a", "\x00b"}} {
+		if cache.Seen(replayKey(pair[0], pair[1]), expires) {
+			t.Log(pair)
+			t.Fail()
+		}
+	}
+}
+
+func TestClientAssertionClaims(t *testing.T) {
+	verifier, rs256, _ := testAssertionVerifier(t)
+	exp := time.Now().Add(time.Minute).Unix()
+	for _, claims := range []*Claims{
+		{Issuer: "client1", Subject: "other", Audience: tokenEndpoint, Expires: exp, Raw: map[string]interface{}{"jti": "1"}},
+		{Issuer: "client1", Subject: "client1", Audience: "https://other.example.com", Expires: exp, Raw: map[string]interface{}{"jti": "2"}},
+		{Issuer: "client1", Subject: "client1", Audience: tokenEndpoint, Expires: exp},
+		{Issuer: "client1", Subject: "client1", Audience: tokenEndpoint, Expires: exp, Raw: map[string]interface{}{"jti": ""}},
+		{Issuer: "client1", Subject: "client1", Audience: tokenEndpoint, Expires: exp, Raw: map[string]interface{}{"jti": 5}},
+		{Issuer: "client1", Subject: "client1", Audience: tokenEndpoint, Expires: time.Now().Add(2 * time.Hour).Unix(), Raw: map[string]interface{}{"jti": "3"}},
+		{Issuer: "client1", Subject: "client1", Audience: tokenEndpoint, Expires: time.Now().Add(-time.Minute).Unix(), Raw: map[string]interface{}{"jti": "4"}},
+	} {
+		assertion, _ := Create(claims, rs256)
+		if _, err := verifier.VerifyClientAssertion(assertion); err == nil {
+			t.Log(claims)
+			t.Fail()
+		}
+	}
+	// the issuer identifier is accepted as audience as well
+	assertion, _ := CreateClientAssertion("client1", atIssuer, rs256)
+	if _, err := verifier.VerifyClientAssertion(assertion); err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestClientAssertionRequest(t *testing.T) {
+	verifier, rs256, _ := testAssertionVerifier(t)
+	assertion, _ := CreateClientAssertion("client1", tokenEndpoint, rs256)
+	params := ClientAssertionParams("client1", assertion)
+	params.Set("grant_type", "client_credentials")
+	r := httptest.NewRequest(http.MethodPost, tokenEndpoint, strings.NewReader(params.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	clientID, _, err := verifier.VerifyRequest(r)
+	if err != nil || clientID != "client1" {
+		t.Log(clientID, err)
+		t.Fail()
+	}
+
+	assertion, _ = CreateClientAssertion("client1", tokenEndpoint, rs256)
+	params = ClientAssertionParams("client2", assertion)
+	r = httptest.NewRequest(http.MethodPost, tokenEndpoint, strings.NewReader(params.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if _, _, err := verifier.VerifyRequest(r); err != ErrInvalidClientID {
+		t.Log(err)
+		t.Fail()
+	}
+	params.Set("client_assertion_type", "other")
+	r = httptest.NewRequest(http.MethodPost, tokenEndpoint, strings.NewReader(params.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if _, _, err := verifier.VerifyRequest(r); err != ErrInvalidClientAssertionType {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestAuthorizationGrant(t *testing.T) {
+	verifier, rs256, _ := testAssertionVerifier(t)
+	grant, err := CreateAuthorizationGrant(&Claims{
+		Issuer:   "client1",
+		Subject:  "user",
+		Audience: tokenEndpoint,
+		Expires:  time.Now().Add(time.Minute).Unix(),
+	}, rs256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := AuthorizationGrantParams(grant)
+	if params.Get("grant_type") != JWT_BEARER_GRANT_TYPE {
+		t.Fail()
+	}
+	token, err := verifier.VerifyAuthorizationGrant(params.Get("assertion"))
+	if err != nil || token.Claims.Subject != "user" {
+		t.Log(err)
+		t.Fail()
+	}
+	if _, err := verifier.VerifyAuthorizationGrant(grant); err != ErrAssertionReplay {
+		t.Log(err)
+		t.Fail()
+	}
+	_, err = CreateAuthorizationGrant(&Claims{Issuer: "client1", Audience: tokenEndpoint, Expires: time.Now().Unix()}, rs256)
+	if _, ok := err.(*MissingClaimError); !ok {
+		t.Log(err)
+		t.Fail()
+	}
+}
@@ -0,0 +1,205 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+)
+
+// KeyResolver returns the algorithm for verifying a token with the given header. The
+// header has not been verified.
+type KeyResolver func(header *JwtHeader) (Algorithm, error)
+
+// ResolveAlgorithm returns a resolver which verifies all tokens with the algorithm.
+func ResolveAlgorithm(algorithm Algorithm) KeyResolver {
+	return func(*JwtHeader) (Algorithm, error) {
+		return algorithm, nil
+	}
+}
+
+// ResolveKeySet returns a resolver which verifies tokens with the matching key of the set.
+func ResolveKeySet(keys *KeySet) KeyResolver {
+	return func(header *JwtHeader) (Algorithm, error) {
+		if err := checkAlgorithm(header.Alg); err != nil {
+			return nil, err
+		}
+		return keys.Lookup(header)
+	}
+}
+
+// BatchResult is the result of verifying a single token of a batch. Err is one of the
+// errors returned by Parse, the error of the resolver or the error of the context.
+type BatchResult struct {
+	// Index is the position of the token in the batch.
+	Index int
+	Token *JwtToken
+	Err   error
+}
+
+// BatchVerifier verifies many tokens with a pool of workers. Results are returned in the
+// order of the tokens. A BatchVerifier may be used from multiple goroutines.
+type BatchVerifier struct {
+	// Resolver selects the algorithm for each token.
+	Resolver KeyResolver
+	// Workers is the number of goroutines verifying tokens. GOMAXPROCS is used if it is not positive.
+	Workers int
+	// Options are applied when parsing each token.
+	Options []Option
+}
+
+// NewBatchVerifier creates a new batch verifier.
+func NewBatchVerifier(resolver KeyResolver, workers int, opts ...Option) *BatchVerifier {
+	return &BatchVerifier{resolver, workers, opts}
+}
+
+type batchJob struct {
+	index int
+	raw   string
+}
+
+// Verify verifies a slice of tokens. The result for each token is at the index of the token.
+// If the context is cancelled, the remaining tokens are not verified and their results hold
+// the error of the context.
+func (v *BatchVerifier) Verify(ctx context.Context, tokens []string) []BatchResult {
+	input := make(chan string)
+	go func() {
+		defer close(input)
+		for _, raw := range tokens {
+			select {
+			case input <- raw:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	results := make([]BatchResult, len(tokens))
+	n := 0
+	for result := range v.VerifyStream(ctx, input) {
+		results[n] = result
+		n++
+	}
+	for ; n < len(tokens); n++ {
+		results[n] = BatchResult{Index: n, Err: ctx.Err()}
+	}
+	return results
+}
+
+// VerifyStream verifies the tokens read from a channel until it is closed or the context is
+// cancelled. Results are sent in the order the tokens were read, the result channel is closed
+// afterwards and must be drained by the caller. Tokens which were read before the context
+// was cancelled, but not yet verified, are reported with the error of the context.
+func (v *BatchVerifier) VerifyStream(ctx context.Context, tokens <-chan string) <-chan BatchResult {
+	workers := v.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	o := newOptions(v.Options)
+	jobs := make(chan batchJob)
+	done := make(chan BatchResult)
+	results := make(chan BatchResult)
+	// window limits the number of tokens in flight, so the results waiting for an earlier
+	// token to be verified do not grow without bounds
+	window := make(chan struct{}, 2*workers)
+
+	go func() {
+		defer close(jobs)
+		for index := 0; ; index++ {
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case raw, ok := <-tokens:
+				if !ok {
+					return
+				}
+				jobs <- batchJob{index, raw}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				done <- v.verify(ctx, job, o)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	go func() {
+		defer close(results)
+		pending := map[int]BatchResult{}
+		next := 0
+		for result := range done {
+			pending[result.Index] = result
+			for {
+				result, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				results <- result
+				<-window
+				next++
+			}
+		}
+	}()
+	return results
+}
+
+func (v *BatchVerifier) verify(ctx context.Context, job batchJob, o *options) BatchResult {
+	result := BatchResult{Index: job.index}
+	if result.Err = ctx.Err(); result.Err != nil {
+		return result
+	}
+	if v.Resolver == nil {
+		result.Err = errors.New("Key resolver can't be nil")
+		return result
+	}
+	unverified, err := decode(job.raw, false, o)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	algorithm, err := v.Resolver(&unverified.header)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Token, result.Err = unverified.verify(algorithm, o)
+	return result
+}
@@ -0,0 +1,171 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func batchTokens(t testing.TB, alg Algorithm, n int) []string {
+	tokens := make([]string, n)
+	for i := range tokens {
+		token, err := Create(&Claims{Subject: strconv.Itoa(i)}, alg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens[i] = token
+	}
+	return tokens
+}
+
+func TestBatchVerify(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	other, _ := NewHS256(testSecret("other"))
+	tokens := batchTokens(t, hs256, 100)
+	tokens[10] = batchTokens(t, other, 1)[0]
+	tokens[20] = "invalid"
+	results := NewBatchVerifier(ResolveAlgorithm(hs256), 4).Verify(context.Background(), tokens)
+	if len(results) != len(tokens) {
+		t.Fatal(len(results))
+	}
+	for i, result := range results {
+		switch {
+		case result.Index != i:
+			t.Log(i, result.Index)
+			t.Fail()
+		case i == 10:
+			if result.Err != ErrInvalidSignature {
+				t.Log(result.Err)
+				t.Fail()
+			}
+		case i == 20:
+			if result.Err != ErrInvalidTokenFormat {
+				t.Log(result.Err)
+				t.Fail()
+			}
+		case result.Err != nil || result.Token.Claims.Subject != strconv.Itoa(i):
+			t.Log(i, result.Err)
+			t.Fail()
+		}
+	}
+}
+
+func TestBatchKeySet(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	rs256, _ := NewRS256(mustReadFixture("rsa"))
+	key1, _ := NewKey("k1", hs256)
+	key2, _ := NewKey("k2", rs256)
+	key3, _ := NewKey("k3", hs256)
+	tokens := append(batchTokens(t, key1, 2), batchTokens(t, key2, 2)...)
+	tokens = append(tokens, batchTokens(t, key3, 1)...)
+	tokens = append(tokens, forge(`{"alg":"none"}`, &Claims{}, nil))
+	results := NewBatchVerifier(ResolveKeySet(NewKeySet(key1, key2)), 0).Verify(context.Background(), tokens)
+	for i, result := range results[:4] {
+		if result.Err != nil {
+			t.Log(i, result.Err)
+			t.Fail()
+		}
+	}
+	if results[4].Err != ErrKeyNotFound || results[5].Err != ErrAlgorithmNone {
+		t.Log(results[4].Err, results[5].Err)
+		t.Fail()
+	}
+}
+
+func TestBatchResolverError(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	errResolver := errors.New("resolver")
+	resolver := func(header *JwtHeader) (Algorithm, error) {
+		return nil, errResolver
+	}
+	results := NewBatchVerifier(resolver, 2).Verify(context.Background(), batchTokens(t, hs256, 3))
+	for _, result := range results {
+		if result.Err != errResolver {
+			t.Log(result.Err)
+			t.Fail()
+		}
+	}
+	results = NewBatchVerifier(nil, 2).Verify(context.Background(), batchTokens(t, hs256, 1))
+	if results[0].Err == nil {
+		t.Fail()
+	}
+}
+
+func TestBatchCancel(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	tokens := batchTokens(t, hs256, 50)
+	ctx, cancel := context.WithCancel(context.Background())
+	verified := 0
+	resolver := func(header *JwtHeader) (Algorithm, error) {
+		verified++
+		if verified == 10 {
+			cancel()
+		}
+		return hs256, nil
+	}
+	results := NewBatchVerifier(resolver, 1).Verify(ctx, tokens)
+	if len(results) != len(tokens) {
+		t.Fatal(len(results))
+	}
+	for i, result := range results {
+		if result.Index != i {
+			t.Log(i, result.Index)
+			t.Fail()
+		}
+	}
+	if results[0].Err != nil || results[len(results)-1].Err != context.Canceled {
+		t.Log(results[0].Err, results[len(results)-1].Err)
+		t.Fail()
+	}
+}
+
+func TestBatchStream(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	tokens := batchTokens(t, hs256, 20)
+	input := make(chan string)
+	go func() {
+		for _, token := range tokens {
+			input <- token
+		}
+		close(input)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	n := 0
+	for result := range NewBatchVerifier(ResolveAlgorithm(hs256), 3).VerifyStream(ctx, input) {
+		if result.Index != n || result.Err != nil || result.Token.Claims.Subject != strconv.Itoa(n) {
+			t.Log(n, result.Err)
+			t.Fail()
+		}
+		n++
+	}
+	if n != len(tokens) {
+		t.Log(n)
+		t.Fail()
+	}
+}
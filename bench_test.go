@@ -0,0 +1,242 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type benchmarkCase struct {
+	name      string
+	algorithm Algorithm
+}
+
+func benchmarkCases(tb testing.TB) []benchmarkCase {
+	rsa := mustReadFixture("rsa")
+	constructors := []struct {
+		name string
+		new  func() (Algorithm, error)
+	}{
+		{JWT_HS256, func() (Algorithm, error) { return NewHS256(testSecret("secret")) }},
+		{JWT_HS348, func() (Algorithm, error) { return NewHS384(testSecret("secret")) }},
+		{JWT_HS512, func() (Algorithm, error) { return NewHS512(testSecret("secret")) }},
+		{JWT_RS256, func() (Algorithm, error) { return NewRS256(rsa) }},
+		{JWT_RS384, func() (Algorithm, error) { return NewRS384(rsa) }},
+		{JWT_RS512, func() (Algorithm, error) { return NewRS512(rsa) }},
+		{JWT_PS256, func() (Algorithm, error) { return NewPS256(rsa) }},
+		{JWT_PS348, func() (Algorithm, error) { return NewPS384(rsa) }},
+		{JWT_PS512, func() (Algorithm, error) { return NewPS512(rsa) }},
+		{JWT_ES256, func() (Algorithm, error) { return NewES256(mustReadFixture("ecdsa_256")) }},
+		{JWT_ES348, func() (Algorithm, error) { return NewES384(mustReadFixture("ecdsa_384")) }},
+		{JWT_ES512, func() (Algorithm, error) { return NewES512(mustReadFixture("ecdsa_521")) }},
+		{JWT_EdDSA, func() (Algorithm, error) {
+			key, err := GenerateKey(JWT_EdDSA)
+			if err != nil {
+				return nil, err
+			}
+			return key.Algorithm, nil
+		}},
+	}
+	cases := make([]benchmarkCase, 0, len(constructors))
+	for _, c := range constructors {
+		alg, err := c.new()
+		if err != nil {
+			tb.Fatal(c.name, err)
+		}
+		cases = append(cases, benchmarkCase{c.name, alg})
+	}
+	return cases
+}
+
+func benchmarkToken(tb testing.TB, alg Algorithm) string {
+	token, err := Create(&Claims{
+		Expires:  time.Now().Add(time.Hour).Unix(),
+		Subject:  "user",
+		Audience: "api",
+		Issuer:   "https://issuer.example.com",
+		Raw: map[string]interface{}{
+			"scope": "read write",
+			"roles": []string{"admin", "user"},
+		},
+	}, alg)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return token
+}
+
+// naiveParse is the parser used before the fast path and serves as the baseline
+// for the benchmarks.
+func naiveParse(token string, alg Algorithm) (*JwtToken, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidTokenFormat
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	var header standardHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(headerJSON, &header.Raw); err != nil {
+		return nil, err
+	}
+	if header.Alg != alg.Name() {
+		return nil, ErrInvalidAlgorithm
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	if err := alg.Verify([]byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	var claims standardClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(payload, &claims.Raw); err != nil {
+		return nil, err
+	}
+	return &JwtToken{JwtHeader(header), Claims(claims), signature}, nil
+}
+
+func BenchmarkParse(b *testing.B) {
+	for _, c := range benchmarkCases(b) {
+		token := benchmarkToken(b, c.algorithm)
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := Parse(token, c.algorithm); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkParseNaive(b *testing.B) {
+	for _, c := range benchmarkCases(b) {
+		token := benchmarkToken(b, c.algorithm)
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := naiveParse(token, c.algorithm); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	token := benchmarkToken(b, hs256)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Decode(token); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseCached(b *testing.B) {
+	for _, c := range benchmarkCases(b) {
+		token := benchmarkToken(b, c.algorithm)
+		cache := NewCache(0, 0)
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := Parse(token, c.algorithm, WithCache(cache)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkParseParallel(b *testing.B) {
+	for _, c := range benchmarkCases(b) {
+		token := benchmarkToken(b, c.algorithm)
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := Parse(token, c.algorithm); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkCreateParallel(b *testing.B) {
+	for _, c := range benchmarkCases(b) {
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := Create(&Claims{Subject: "user"}, c.algorithm); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkBatch(b *testing.B) {
+	rs256, _ := NewRS256(mustReadFixture("rsa"))
+	tokens := batchTokens(b, rs256, 256)
+	for _, workers := range []int{1, 2, 4, 8} {
+		verifier := NewBatchVerifier(ResolveAlgorithm(rs256), workers)
+		b.Run(strconv.Itoa(workers), func(b *testing.B) {
+			b.ReportAllocs()
+			start := time.Now()
+			for i := 0; i < b.N; i++ {
+				for _, result := range verifier.Verify(context.Background(), tokens) {
+					if result.Err != nil {
+						b.Fatal(result.Err)
+					}
+				}
+			}
+			b.ReportMetric(float64(b.N*len(tokens))/time.Since(start).Seconds(), "tokens/s")
+		})
+	}
+}
@@ -0,0 +1,238 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"reflect"
+	"sync"
+	"time"
+)
+
+const (
+	CACHE_DEFAULT_SIZE = 1024
+	CACHE_DEFAULT_TTL  = 5 * time.Minute
+)
+
+// Cache is a least recently used cache of verified tokens. Tokens are identified by the
+// SHA-256 hash of their encoding and kept until they expire, at most for the maximum TTL.
+// A cached token is only returned if it is parsed with the algorithm it was verified with,
+// or with a key set which still resolves its header to the same key, and with the same
+// limits, critical header registry and unsecured token setting. A Cache may be used from
+// multiple goroutines.
+type Cache struct {
+	mutex   sync.Mutex
+	size    int
+	maxTTL  time.Duration
+	entries map[[sha256.Size]byte]*list.Element
+	lru     *list.List
+	stats   CacheStats
+	now     func() time.Time
+}
+
+type cacheEntry struct {
+	hash         [sha256.Size]byte
+	token        *JwtToken
+	algorithm    Algorithm
+	verification verification
+	expires      time.Time
+}
+
+// CacheStats holds the counters of a cache.
+type CacheStats struct {
+	// Hits is the number of tokens returned from the cache.
+	Hits uint64
+	// Misses is the number of tokens which had to be verified.
+	Misses uint64
+	// Evictions is the number of tokens removed because the cache was full.
+	Evictions uint64
+	// Invalidations is the number of tokens removed by one of the Invalidate methods.
+	Invalidations uint64
+	// Size is the number of tokens in the cache.
+	Size int
+}
+
+// HitRate returns the ratio of hits to lookups.
+func (s CacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// NewCache creates a cache holding up to size tokens for at most maxTTL. Zero values
+// select CACHE_DEFAULT_SIZE and CACHE_DEFAULT_TTL.
+func NewCache(size int, maxTTL time.Duration) *Cache {
+	if size <= 0 {
+		size = CACHE_DEFAULT_SIZE
+	}
+	if maxTTL <= 0 {
+		maxTTL = CACHE_DEFAULT_TTL
+	}
+	return &Cache{
+		size:    size,
+		maxTTL:  maxTTL,
+		entries: map[[sha256.Size]byte]*list.Element{},
+		lru:     list.New(),
+		now:     time.Now,
+	}
+}
+
+// WithCache makes Parse and ParseWithKeySet return verified tokens from the cache and
+// store newly verified tokens in it.
+func WithCache(cache *Cache) Option {
+	return func(o *options) {
+		o.cache = cache
+	}
+}
+
+// Stats returns the counters of the cache.
+func (c *Cache) Stats() CacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	stats := c.stats
+	stats.Size = c.lru.Len()
+	return stats
+}
+
+// Invalidate removes a token from the cache, e.g. after it has been revoked.
+func (c *Cache) Invalidate(token string) {
+	hash := sha256.Sum256([]byte(token))
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if element, ok := c.entries[hash]; ok {
+		c.remove(element)
+		c.stats.Invalidations++
+	}
+}
+
+// InvalidateAlgorithm removes all tokens verified with the algorithm, e.g. after the key
+// has been rotated. It returns the number of removed tokens.
+func (c *Cache) InvalidateAlgorithm(algorithm Algorithm) int {
+	return c.InvalidateFunc(func(token *JwtToken, verifiedWith Algorithm) bool {
+		return verifiedWith == algorithm
+	})
+}
+
+// InvalidateFunc removes all tokens for which remove returns true, e.g. all tokens of a
+// revoked subject. It returns the number of removed tokens. The token must not be modified.
+func (c *Cache) InvalidateFunc(remove func(token *JwtToken, verifiedWith Algorithm) bool) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	removed := 0
+	for element := c.lru.Front(); element != nil; {
+		next := element.Next()
+		entry := element.Value.(*cacheEntry)
+		if remove(entry.token, entry.algorithm) {
+			c.remove(element)
+			removed++
+		}
+		element = next
+	}
+	c.stats.Invalidations += uint64(removed)
+	return removed
+}
+
+// Purge removes all tokens from the cache.
+func (c *Cache) Purge() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.stats.Invalidations += uint64(c.lru.Len())
+	c.entries = map[[sha256.Size]byte]*list.Element{}
+	c.lru.Init()
+}
+
+// get returns a copy of the cached token if it was verified with the same options and
+// accept returns true for the algorithm it was verified with.
+func (c *Cache) get(token string, o *options, accept func(entry *cacheEntry) bool) *JwtToken {
+	hash := sha256.Sum256([]byte(token))
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	element, ok := c.entries[hash]
+	if !ok {
+		c.stats.Misses++
+		return nil
+	}
+	entry := element.Value.(*cacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.remove(element)
+		c.stats.Misses++
+		return nil
+	}
+	if entry.verification != o.verification() || !accept(entry) {
+		c.stats.Misses++
+		return nil
+	}
+	c.lru.MoveToFront(element)
+	c.stats.Hits++
+	return entry.token.copy()
+}
+
+// put stores a copy of a verified token until it expires.
+func (c *Cache) put(token string, verified *JwtToken, algorithm Algorithm, o *options) {
+	if !reflect.TypeOf(algorithm).Comparable() {
+		return
+	}
+	now := c.now()
+	expires := now.Add(c.maxTTL)
+	if exp := verified.Claims.Expires; exp != 0 && time.Unix(exp, 0).Before(expires) {
+		expires = time.Unix(exp, 0)
+	}
+	if !now.Before(expires) {
+		return
+	}
+	hash := sha256.Sum256([]byte(token))
+	entry := &cacheEntry{hash, verified.copy(), algorithm, o.verification(), expires}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if element, ok := c.entries[hash]; ok {
+		element.Value = entry
+		c.lru.MoveToFront(element)
+		return
+	}
+	c.entries[hash] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.size {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+func (c *Cache) remove(element *list.Element) {
+	delete(c.entries, element.Value.(*cacheEntry).hash)
+	c.lru.Remove(element)
+}
+
+// copy returns a copy of the token which does not share the header and the top level claims.
+func (t *JwtToken) copy() *JwtToken {
+	claims := t.Claims
+	if claims.Raw != nil {
+		raw := make(map[string]interface{}, len(claims.Raw))
+		for name, value := range claims.Raw {
+			raw[name] = value
+		}
+		claims.Raw = raw
+	}
+	return &JwtToken{t.Header.copy(), claims, t.signature}
+}
@@ -0,0 +1,265 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func cacheToken(t *testing.T, alg Algorithm, subject string, expires time.Time) string {
+	token, err := Create(&Claims{Subject: subject, Expires: expires.Unix()}, alg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestCacheHit(t *testing.T) {
+	rs256, _ := NewRS256(mustReadFixture("rsa"))
+	token := cacheToken(t, rs256, "subject", time.Now().Add(time.Hour))
+	cache := NewCache(0, 0)
+	first, err := Parse(token, rs256, WithCache(cache))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Claims.Raw["sub"] = "changed"
+	first.Claims.Subject = "changed"
+	second, err := Parse(token, rs256, WithCache(cache))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second == first || second.Claims.Subject != "subject" || second.Claims.Raw["sub"] != "subject" {
+		t.Log(second.Claims)
+		t.Fail()
+	}
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Size != 1 || stats.HitRate() != 0.5 {
+		t.Log(stats)
+		t.Fail()
+	}
+}
+
+func TestCacheAlgorithmMismatch(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	other, _ := NewHS256(testSecret("other"))
+	token := cacheToken(t, hs256, "subject", time.Now().Add(time.Hour))
+	cache := NewCache(0, 0)
+	if _, err := Parse(token, hs256, WithCache(cache)); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Parse(token, other, WithCache(cache))
+	if err != ErrInvalidSignature {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestCacheOptionsMismatch(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	key, _ := NewKey("key1", hs256)
+	token := cacheToken(t, key, "subject", time.Now().Add(time.Hour))
+	cache := NewCache(0, 0)
+	if _, err := Parse(token, key, WithCache(cache)); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Parse(token, key, WithCache(cache), WithLimits(Limits{MaxTokenLength: 16}))
+	if err != ErrTokenTooLarge {
+		t.Log(err)
+		t.Fail()
+	}
+	_, err = ParseWithKeySet(token, NewKeySet(key), WithCache(cache), WithLimits(Limits{MaxTokenLength: 16}))
+	if err != ErrTokenTooLarge {
+		t.Log(err)
+		t.Fail()
+	}
+	if stats := cache.Stats(); stats.Hits != 0 {
+		t.Log(stats)
+		t.Fail()
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	now := time.Now()
+	cache := NewCache(0, time.Minute)
+	cache.now = func() time.Time { return now }
+	short := cacheToken(t, hs256, "short", now.Add(10*time.Second))
+	long := cacheToken(t, hs256, "long", now.Add(time.Hour))
+	expired := cacheToken(t, hs256, "expired", now.Add(-time.Second))
+	for _, token := range []string{short, long, expired} {
+		if _, err := Parse(token, hs256, WithCache(cache)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if size := cache.Stats().Size; size != 2 {
+		t.Log(size)
+		t.Fail()
+	}
+	now = now.Add(30 * time.Second)
+	Parse(short, hs256, WithCache(cache))
+	Parse(long, hs256, WithCache(cache))
+	now = now.Add(time.Minute)
+	Parse(long, hs256, WithCache(cache))
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 5 {
+		t.Log(stats)
+		t.Fail()
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	cache := NewCache(2, 0)
+	tokens := []string{}
+	for _, subject := range []string{"a", "b", "c"} {
+		token := cacheToken(t, hs256, subject, time.Now().Add(time.Hour))
+		tokens = append(tokens, token)
+		Parse(token, hs256, WithCache(cache))
+	}
+	Parse(tokens[0], hs256, WithCache(cache))
+	Parse(tokens[2], hs256, WithCache(cache))
+	stats := cache.Stats()
+	if stats.Evictions != 2 || stats.Hits != 1 || stats.Size != 2 {
+		t.Log(stats)
+		t.Fail()
+	}
+}
+
+func TestCacheKeyRotation(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	key, _ := NewKey("k1", hs256)
+	keys := NewKeySet(key)
+	token := cacheToken(t, key, "subject", time.Now().Add(time.Hour))
+	cache := NewCache(0, 0)
+	if _, err := ParseWithKeySet(token, keys, WithCache(cache)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseWithKeySet(token, keys, WithCache(cache)); err != nil {
+		t.Fatal(err)
+	}
+	keys.Remove("k1")
+	_, err := ParseWithKeySet(token, keys, WithCache(cache))
+	if err != ErrKeyNotFound {
+		t.Log(err)
+		t.Fail()
+	}
+	if hits := cache.Stats().Hits; hits != 1 {
+		t.Log(hits)
+		t.Fail()
+	}
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	hs512, _ := NewHS512(testSecret("secret"))
+	cache := NewCache(0, 0)
+	tokens := []string{
+		cacheToken(t, hs256, "a", time.Now().Add(time.Hour)),
+		cacheToken(t, hs256, "b", time.Now().Add(time.Hour)),
+		cacheToken(t, hs256, "c", time.Now().Add(time.Hour)),
+		cacheToken(t, hs512, "d", time.Now().Add(time.Hour)),
+	}
+	for i, token := range tokens {
+		alg := Algorithm(hs256)
+		if i == 3 {
+			alg = hs512
+		}
+		Parse(token, alg, WithCache(cache))
+	}
+	cache.Invalidate(tokens[0])
+	if removed := cache.InvalidateFunc(func(token *JwtToken, _ Algorithm) bool {
+		return token.Claims.Subject == "b"
+	}); removed != 1 {
+		t.Log(removed)
+		t.Fail()
+	}
+	if removed := cache.InvalidateAlgorithm(hs512); removed != 1 {
+		t.Log(removed)
+		t.Fail()
+	}
+	stats := cache.Stats()
+	if stats.Size != 1 || stats.Invalidations != 3 {
+		t.Log(stats)
+		t.Fail()
+	}
+	cache.Purge()
+	if stats := cache.Stats(); stats.Size != 0 || stats.Invalidations != 4 {
+		t.Log(stats)
+		t.Fail()
+	}
+}
+
+func TestCacheConcurrent(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	cache := NewCache(4, 0)
+	tokens := []string{}
+	for _, subject := range []string{"a", "b", "c", "d", "e", "f"} {
+		tokens = append(tokens, cacheToken(t, hs256, subject, time.Now().Add(time.Hour)))
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				token := tokens[(i+j)%len(tokens)]
+				if _, err := Parse(token, hs256, WithCache(cache)); err != nil {
+					t.Error(err)
+					return
+				}
+				if j%10 == 0 {
+					cache.Invalidate(token)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	stats := cache.Stats()
+	if stats.Hits+stats.Misses != 800 || stats.Size > 4 {
+		t.Log(stats)
+		t.Fail()
+	}
+}
+
+func TestMiddlewareCache(t *testing.T) {
+	m, raw := newTestMiddleware(t, ValidateIssuer(""))
+	m.Cache = NewCache(0, 0)
+	for i := 0; i < 3; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer "+raw)
+		w, token := serve(m, r)
+		if w.Code != http.StatusOK || token == nil {
+			t.Log(w.Code)
+			t.Fail()
+		}
+	}
+	if hits := m.Cache.Stats().Hits; hits != 2 {
+		t.Log(hits)
+		t.Fail()
+	}
+}
@@ -0,0 +1,109 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// timeClaims are printed in human-readable form.
+var timeClaims = []string{"iat", "nbf", "exp", "auth_time"}
+
+func decode(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("decode", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	token, err := readToken(flags.Args(), stdin)
+	if err != nil {
+		return err
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errors.New("invalid token format")
+	}
+	header, err := decodeSegment(parts[0])
+	if err != nil {
+		return errors.New("invalid header: " + err.Error())
+	}
+	claims, err := decodeSegment(parts[1])
+	if err != nil {
+		return errors.New("invalid claims: " + err.Error())
+	}
+	fmt.Fprintln(stdout, "Header:")
+	fmt.Fprintln(stdout, header)
+	fmt.Fprintln(stdout, "Claims:")
+	fmt.Fprintln(stdout, claims)
+	printTimes(stdout, parts[1], time.Now())
+	return nil
+}
+
+func decodeSegment(segment string) (string, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return "", err
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, decoded, "", "  "); err != nil {
+		return "", err
+	}
+	return indented.String(), nil
+}
+
+func printTimes(w io.Writer, segment string, now time.Time) {
+	decoded, _ := base64.RawURLEncoding.DecodeString(segment)
+	var claims map[string]interface{}
+	if json.Unmarshal(decoded, &claims) != nil {
+		return
+	}
+	header := false
+	for _, name := range timeClaims {
+		value, ok := claims[name].(float64)
+		if !ok || value == 0 {
+			continue
+		}
+		if !header {
+			fmt.Fprintln(w, "Times:")
+			header = true
+		}
+		fmt.Fprintf(w, "  %-9s %s\n", name, formatTime(time.Unix(int64(value), 0), now))
+	}
+}
+
+func formatTime(t, now time.Time) string {
+	formatted := t.UTC().Format(time.RFC3339)
+	diff := t.Sub(now).Truncate(time.Second)
+	if diff >= 0 {
+		return formatted + " (in " + diff.String() + ")"
+	}
+	return formatted + " (" + (-diff).String() + " ago)"
+}
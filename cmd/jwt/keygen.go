@@ -0,0 +1,125 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/tezli/jwt"
+)
+
+// algorithmNames maps algorithm names to the names used by the package.
+var algorithmNames = map[string]string{
+	"ES256": jwt.JWT_ES256,
+	"ES384": jwt.JWT_ES348,
+	"ES512": jwt.JWT_ES512,
+	"EDDSA": jwt.JWT_EdDSA,
+	"HS256": jwt.JWT_HS256,
+	"HS384": jwt.JWT_HS348,
+	"HS512": jwt.JWT_HS512,
+	"PS256": jwt.JWT_PS256,
+	"PS384": jwt.JWT_PS348,
+	"PS512": jwt.JWT_PS512,
+	"RS256": jwt.JWT_RS256,
+	"RS384": jwt.JWT_RS384,
+	"RS512": jwt.JWT_RS512,
+}
+
+func keygen(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("keygen", flag.ContinueOnError)
+	name := flags.String("alg", "", "algorithm the key is generated for")
+	bits := flags.Int("bits", jwt.RSA_DEFAULT_BITS, "RSA key size, ignored for other algorithms")
+	asJWK := flags.Bool("jwk", false, "write keys as JWK instead of PEM")
+	out := flags.String("out", "", "file the private key is written to instead of stdout")
+	pub := flags.String("pub", "", "file the public key is written to")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return errors.New("keygen requires -alg")
+	}
+	alg, ok := algorithmNames[strings.ToUpper(*name)]
+	if !ok {
+		return fmt.Errorf("unsupported algorithm %q", *name)
+	}
+	var key *jwt.GeneratedKey
+	var err error
+	switch alg {
+	case jwt.JWT_RS256, jwt.JWT_RS384, jwt.JWT_RS512, jwt.JWT_PS256, jwt.JWT_PS348, jwt.JWT_PS512:
+		key, err = jwt.GenerateRSAKey(alg, *bits)
+	default:
+		key, err = jwt.GenerateKey(alg)
+	}
+	if err != nil {
+		return err
+	}
+	private, public, err := exportKey(key, *asJWK)
+	if err != nil {
+		return err
+	}
+	if *pub != "" {
+		if public == nil {
+			return errors.New("HMAC keys have no public key")
+		}
+		if err := os.WriteFile(*pub, public, 0644); err != nil {
+			return err
+		}
+	}
+	if *out != "" {
+		return os.WriteFile(*out, private, 0600)
+	}
+	_, err = stdout.Write(private)
+	return err
+}
+
+// exportKey returns the private and public key as PEM or JWK. HMAC secrets are exported
+// base64url encoded if PEM is requested, which is the format sign and verify read, and
+// have no public key.
+func exportKey(key *jwt.GeneratedKey, asJWK bool) ([]byte, []byte, error) {
+	if !asJWK {
+		if key.Secret != nil {
+			return []byte(base64.RawURLEncoding.EncodeToString(key.Secret) + "\n"), nil, nil
+		}
+		return key.PrivateKeyPEM, key.PublicKeyPEM, nil
+	}
+	private, err := json.MarshalIndent(key.PrivateJWK, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	if key.PublicJWK == nil {
+		return append(private, '\n'), nil, nil
+	}
+	public, err := json.MarshalIndent(key.PublicJWK, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(private, '\n'), append(public, '\n'), nil
+}
@@ -0,0 +1,185 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+// Command jwt decodes, signs and verifies JSON Web Tokens and generates keys. It works offline.
+//
+// Usage:
+//
+//	jwt decode [token]
+//	jwt sign -alg ES256 -key key.pem [-claims claims.json] [-sub subject] [-iss issuer] [-aud audience] [-exp 1h]
+//	jwt verify -alg ES256 -key key.pem [token]
+//	jwt keygen -alg ES256 [-bits 2048] [-jwk] [-out key.pem] [-pub key.pub]
+//
+// Tokens and claims are read from standard input if not given as argument or if "-" is given.
+// HMAC secrets are base64url encoded. verify also accepts the public keys written by keygen -pub.
+// Tokens without an exp claim don't expire.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/tezli/jwt"
+)
+
+const (
+	exitOK = iota
+	exitError
+	exitMalformed
+	exitInvalidAlgorithm
+	exitInvalidSignature
+	exitExpired
+	exitNotValidYet
+)
+
+const usage = `Usage: jwt <command> [flags]
+
+Commands:
+  decode   Print header and claims of a token without verifying it
+  sign     Create a token from claims
+  verify   Verify a token and print its claims
+  keygen   Generate a key for an algorithm
+
+Exit codes of verify:
+  0  token is valid
+  1  usage or I/O error
+  2  token is malformed
+  3  algorithm does not match
+  4  signature is invalid
+  5  token is expired (tokens without exp don't expire)
+  6  token is not valid yet
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitError
+	}
+	var err error
+	code := exitOK
+	switch args[0] {
+	case "decode":
+		err = decode(args[1:], stdin, stdout)
+	case "sign":
+		err = sign(args[1:], stdin, stdout)
+	case "verify":
+		code, err = verify(args[1:], stdin, stdout)
+	case "keygen":
+		err = keygen(args[1:], stdout)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	default:
+		fmt.Fprint(stderr, usage)
+		return exitError
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "jwt: "+err.Error())
+		if code == exitOK {
+			code = exitError
+		}
+	}
+	return code
+}
+
+// readToken reads the token from the first argument or from stdin.
+func readToken(args []string, stdin io.Reader) (string, error) {
+	if len(args) > 0 && args[0] != "-" {
+		return strings.TrimSpace(args[0]), nil
+	}
+	line, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// newAlgorithm creates an algorithm from its name and a key. HMAC secrets are base64url
+// encoded as written by keygen, all other keys must be PEM encoded private keys or, for
+// verifying, PEM encoded public keys.
+func newAlgorithm(name string, key []byte) (jwt.Algorithm, error) {
+	switch strings.ToUpper(name) {
+	case "HS256", "HS384", "HS512":
+		secret, err := base64.RawURLEncoding.DecodeString(string(bytes.TrimSpace(key)))
+		if err != nil {
+			return nil, errors.New("HMAC secret must be base64url encoded")
+		}
+		key = secret
+	}
+	if block, _ := pem.Decode(key); block != nil && strings.HasSuffix(block.Type, "PUBLIC KEY") {
+		alg, ok := algorithmNames[strings.ToUpper(name)]
+		if !ok {
+			return nil, fmt.Errorf("unsupported algorithm %q", name)
+		}
+		return jwt.NewVerifierFromPEM(alg, key)
+	}
+	switch strings.ToUpper(name) {
+	case "HS256":
+		return algorithm(jwt.NewHS256(key))
+	case "HS384":
+		return algorithm(jwt.NewHS384(key))
+	case "HS512":
+		return algorithm(jwt.NewHS512(key))
+	case "RS256":
+		return algorithm(jwt.NewRS256(key))
+	case "RS384":
+		return algorithm(jwt.NewRS384(key))
+	case "RS512":
+		return algorithm(jwt.NewRS512(key))
+	case "ES256":
+		return algorithm(jwt.NewES256(key))
+	case "ES384":
+		return algorithm(jwt.NewES384(key))
+	case "ES512":
+		return algorithm(jwt.NewES512(key))
+	case "PS256":
+		return algorithm(jwt.NewPS256(key))
+	case "PS384":
+		return algorithm(jwt.NewPS384(key))
+	case "PS512":
+		return algorithm(jwt.NewPS512(key))
+	case "EDDSA":
+		return algorithm(jwt.NewEdDSA(key))
+	}
+	return nil, fmt.Errorf("unsupported algorithm %q", name)
+}
+
+// algorithm avoids returning typed nil pointers as non-nil interfaces.
+func algorithm[T jwt.Algorithm](alg T, err error) (jwt.Algorithm, error) {
+	if err != nil {
+		return nil, err
+	}
+	return alg, nil
+}
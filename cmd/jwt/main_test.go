@@ -0,0 +1,240 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tezli/jwt"
+)
+
+// secret is long enough for every HMAC algorithm, encodedSecret is its key file content.
+var (
+	secret        = []byte("jwt command test secret, long enough for every HMAC algorithm...")
+	encodedSecret = base64.RawURLEncoding.EncodeToString(secret)
+)
+
+func runCommand(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestKeygenSignVerify(t *testing.T) {
+	dir := t.TempDir()
+	for _, alg := range []string{"HS256", "HS384", "HS512", "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"} {
+		keyFile := filepath.Join(dir, alg)
+		code, _, stderr := runCommand("", "keygen", "-alg", alg, "-out", keyFile)
+		if code != exitOK {
+			t.Log(alg, stderr)
+			t.Fail()
+			continue
+		}
+		code, token, stderr := runCommand(`{"scope":"read"}`, "sign", "-alg", alg, "-key", keyFile, "-claims", "-", "-sub", "subject")
+		if code != exitOK {
+			t.Log(alg, stderr)
+			t.Fail()
+			continue
+		}
+		code, claims, stderr := runCommand(token, "verify", "-alg", alg, "-key", keyFile)
+		if code != exitOK || !strings.Contains(claims, `"scope": "read"`) || !strings.Contains(claims, `"sub": "subject"`) {
+			t.Log(alg, claims, stderr)
+			t.Fail()
+		}
+	}
+}
+
+func TestKeygenJWK(t *testing.T) {
+	dir := t.TempDir()
+	pub := filepath.Join(dir, "key.pub")
+	code, private, stderr := runCommand("", "keygen", "-alg", "ES256", "-jwk", "-pub", pub)
+	if code != exitOK || !strings.Contains(private, `"d":`) {
+		t.Log(stderr)
+		t.Fail()
+	}
+	public, _ := os.ReadFile(pub)
+	if !strings.Contains(string(public), `"crv": "P-256"`) || strings.Contains(string(public), `"d":`) {
+		t.Log(string(public))
+		t.Fail()
+	}
+	code, _, _ = runCommand("", "keygen", "-alg", "HS256", "-pub", pub)
+	if code != exitError {
+		t.Fail()
+	}
+}
+
+func TestVerifyExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	os.WriteFile(keyFile, []byte(encodedSecret+"\n"), 0600)
+
+	_, expired, _ := runCommand(`{"exp":1}`, "sign", "-alg", "HS256", "-key", keyFile, "-claims", "-")
+	code, _, _ := runCommand(expired, "verify", "-alg", "HS256", "-key", keyFile)
+	if code != exitExpired {
+		t.Log(code)
+		t.Fail()
+	}
+
+	nbf := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	_, early, _ := runCommand(`{"nbf":`+nbf+`}`, "sign", "-alg", "HS256", "-key", keyFile, "-claims", "-")
+	code, _, _ = runCommand(early, "verify", "-alg", "HS256", "-key", keyFile)
+	if code != exitNotValidYet {
+		t.Log(code)
+		t.Fail()
+	}
+
+	_, token, _ := runCommand("", "sign", "-alg", "HS256", "-key", keyFile)
+	code, _, _ = runCommand(token, "verify", "-alg", "HS512", "-key", keyFile)
+	if code != exitInvalidAlgorithm {
+		t.Log(code)
+		t.Fail()
+	}
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	signature := strings.Split(strings.TrimSpace(expired), ".")[2]
+	code, _, _ = runCommand(parts[0]+"."+parts[1]+"."+signature, "verify", "-alg", "HS256", "-key", keyFile)
+	if code != exitInvalidSignature {
+		t.Log(code)
+		t.Fail()
+	}
+	code, _, _ = runCommand("invalid", "verify", "-alg", "HS256", "-key", keyFile)
+	if code != exitMalformed {
+		t.Log(code)
+		t.Fail()
+	}
+	code, _, _ = runCommand(token, "verify", "-alg", "HS256")
+	if code != exitError {
+		t.Log(code)
+		t.Fail()
+	}
+	for _, header := range []string{`{"alg":"none"}`, `{"alg":"XS256"}`} {
+		forged := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + parts[1] + "." + parts[2]
+		code, _, _ = runCommand(forged, "verify", "-alg", "HS256", "-key", keyFile)
+		if code != exitInvalidAlgorithm {
+			t.Log(header, code)
+			t.Fail()
+		}
+	}
+}
+
+func TestVerifyWithoutExpiry(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	os.WriteFile(keyFile, []byte(encodedSecret), 0600)
+	alg, _ := jwt.NewHS256(secret)
+	token, _ := jwt.Create(&jwt.Claims{Subject: "subject"}, alg)
+	code, claims, stderr := runCommand(token, "verify", "-alg", "HS256", "-key", keyFile)
+	if code != exitOK || strings.Contains(claims, `"exp"`) {
+		t.Log(code, claims, stderr)
+		t.Fail()
+	}
+}
+
+func TestVerifyPublicKey(t *testing.T) {
+	dir := t.TempDir()
+	for _, alg := range []string{"RS256", "PS384", "ES512", "EdDSA"} {
+		keyFile, pubFile := filepath.Join(dir, alg), filepath.Join(dir, alg+".pub")
+		runCommand("", "keygen", "-alg", alg, "-out", keyFile, "-pub", pubFile)
+		_, token, _ := runCommand("", "sign", "-alg", alg, "-key", keyFile)
+		code, _, stderr := runCommand(token, "verify", "-alg", alg, "-key", pubFile)
+		if code != exitOK {
+			t.Log(alg, stderr)
+			t.Fail()
+		}
+		code, _, _ = runCommand("", "sign", "-alg", alg, "-key", pubFile)
+		if code != exitError {
+			t.Log(alg, code)
+			t.Fail()
+		}
+	}
+}
+
+func TestKeygenHMACSecret(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	runCommand("", "keygen", "-alg", "HS256", "-out", keyFile)
+	_, token, _ := runCommand("", "sign", "-alg", "HS256", "-key", keyFile)
+	encoded, _ := os.ReadFile(keyFile)
+	secret, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	alg, _ := jwt.NewHS256(secret)
+	if _, err := jwt.Parse(strings.TrimSpace(token), alg); err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestDecode(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	os.WriteFile(keyFile, []byte(encodedSecret), 0600)
+	_, token, _ := runCommand("", "sign", "-alg", "HS256", "-key", keyFile, "-iss", "issuer", "-aud", "audience")
+	code, output, stderr := runCommand("", "decode", strings.TrimSpace(token))
+	if code != exitOK {
+		t.Log(stderr)
+		t.FailNow()
+	}
+	for _, expected := range []string{`"alg": "HS256"`, `"iss": "issuer"`, `"aud": "audience"`, "Times:", "exp", "(in "} {
+		if !strings.Contains(output, expected) {
+			t.Log(output)
+			t.Fail()
+		}
+	}
+	code, _, _ = runCommand("a.b.c", "decode")
+	if code != exitError {
+		t.Log(code)
+		t.Fail()
+	}
+}
+
+func TestUsage(t *testing.T) {
+	code, _, _ := runCommand("")
+	if code != exitError {
+		t.Fail()
+	}
+	code, _, _ = runCommand("", "unknown")
+	if code != exitError {
+		t.Fail()
+	}
+	code, output, _ := runCommand("", "help")
+	if code != exitOK || !strings.Contains(output, "Usage") {
+		t.Fail()
+	}
+	code, _, _ = runCommand("", "keygen", "-alg", "RS256", "-bits", "1024")
+	if code != exitError {
+		t.Fail()
+	}
+	code, _, _ = runCommand("", "keygen", "-alg", "none")
+	if code != exitError {
+		t.Fail()
+	}
+}
@@ -0,0 +1,93 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/tezli/jwt"
+)
+
+func sign(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("sign", flag.ContinueOnError)
+	name := flags.String("alg", "", "signing algorithm")
+	keyFile := flags.String("key", "", "private key or HMAC secret file")
+	claimsFile := flags.String("claims", "", `claims JSON file or "-" for stdin`)
+	subject := flags.String("sub", "", "subject claim")
+	issuer := flags.String("iss", "", "issuer claim")
+	audience := flags.String("aud", "", "audience claim")
+	lifetime := flags.Duration("exp", time.Hour, "lifetime of the token")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" || *keyFile == "" {
+		return errors.New("sign requires -alg and -key")
+	}
+	key, err := os.ReadFile(*keyFile)
+	if err != nil {
+		return err
+	}
+	alg, err := newAlgorithm(*name, key)
+	if err != nil {
+		return err
+	}
+	claims := &jwt.Claims{}
+	if *claimsFile != "" {
+		var data []byte
+		if *claimsFile == "-" {
+			data, err = io.ReadAll(stdin)
+		} else {
+			data, err = os.ReadFile(*claimsFile)
+		}
+		if err != nil {
+			return err
+		}
+		if err := claims.UnmarshalJSON(data); err != nil {
+			return errors.New("invalid claims: " + err.Error())
+		}
+	}
+	if *subject != "" {
+		claims.Subject = *subject
+	}
+	if *issuer != "" {
+		claims.Issuer = *issuer
+	}
+	if *audience != "" {
+		claims.Audience = *audience
+	}
+	if claims.Expires == 0 {
+		claims.Expires = time.Now().Add(*lifetime).Unix()
+	}
+	token, err := jwt.Create(claims, alg)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, token)
+	return nil
+}
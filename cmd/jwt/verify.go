@@ -0,0 +1,100 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/tezli/jwt"
+)
+
+func verify(args []string, stdin io.Reader, stdout io.Writer) (int, error) {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	name := flags.String("alg", "", "signing algorithm")
+	keyFile := flags.String("key", "", "public key, private key or HMAC secret file")
+	if err := flags.Parse(args); err != nil {
+		return exitError, err
+	}
+	if *name == "" || *keyFile == "" {
+		return exitError, errors.New("verify requires -alg and -key")
+	}
+	key, err := os.ReadFile(*keyFile)
+	if err != nil {
+		return exitError, err
+	}
+	alg, err := newAlgorithm(*name, key)
+	if err != nil {
+		return exitError, err
+	}
+	raw, err := readToken(flags.Args(), stdin)
+	if err != nil {
+		return exitError, err
+	}
+	token, err := jwt.Parse(raw, alg)
+	if err == nil {
+		err = validateTimes(token)
+	}
+	if err != nil {
+		return exitCode(err), err
+	}
+	claims, err := json.MarshalIndent(token.Claims, "", "  ")
+	if err != nil {
+		return exitError, err
+	}
+	fmt.Fprintln(stdout, string(claims))
+	return exitOK, nil
+}
+
+// validateTimes checks exp and nbf. Unlike JwtToken.Validate it treats a missing exp as
+// "does not expire".
+func validateTimes(token *jwt.JwtToken) error {
+	if token.Claims.Expires != 0 && token.IsExpired() {
+		return jwt.ErrTokenExpired
+	}
+	if token.Claims.NotBefore > time.Now().Unix() {
+		return jwt.ErrTokenNotValidYet
+	}
+	return nil
+}
+
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, jwt.ErrInvalidAlgorithm), errors.Is(err, jwt.ErrUnknownAlgorithm),
+		errors.Is(err, jwt.ErrAlgorithmNone), errors.Is(err, jwt.ErrAlgorithmNotAllowed):
+		return exitInvalidAlgorithm
+	case errors.Is(err, jwt.ErrInvalidSignature):
+		return exitInvalidSignature
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return exitExpired
+	case errors.Is(err, jwt.ErrTokenNotValidYet):
+		return exitNotValidYet
+	}
+	return exitMalformed
+}
@@ -0,0 +1,117 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"crypto"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+var (
+	ErrNoClientCertificate = errors.New("Request has no TLS client certificate")
+	ErrCertificateBinding  = errors.New("Access token is not bound to the client certificate")
+)
+
+// Confirmation is the "cnf" claim of proof-of-possession tokens (RFC 7800). It identifies the
+// key the presenter of the token must prove possession of.
+type Confirmation struct {
+	// JWK is the public key (RFC 7800 section 3.2).
+	JWK *JWK `json:"jwk,omitempty"`
+	// JKT is the JWK SHA-256 thumbprint of a DPoP key (RFC 9449 section 6.1).
+	JKT string `json:"jkt,omitempty"`
+	// Kid identifies a key known to the recipient (RFC 7800 section 3.4).
+	Kid string `json:"kid,omitempty"`
+	// X5tS256 is the SHA-256 thumbprint of a TLS client certificate (RFC 8705 section 3.1).
+	X5tS256 string `json:"x5t#S256,omitempty"`
+}
+
+// Confirmation returns the "cnf" claim. It returns nil if the claim is not present.
+func (c *Claims) Confirmation() (*Confirmation, error) {
+	value, ok := c.Raw["cnf"]
+	if !ok {
+		return nil, nil
+	}
+	if cnf, ok := value.(*Confirmation); ok {
+		return cnf, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	cnf := &Confirmation{}
+	if err := json.Unmarshal(data, cnf); err != nil {
+		return nil, errors.New("Invalid cnf claim")
+	}
+	return cnf, nil
+}
+
+// SetConfirmation sets the "cnf" claim.
+func (c *Claims) SetConfirmation(cnf *Confirmation) {
+	if c.Raw == nil {
+		c.Raw = map[string]interface{}{}
+	}
+	c.Raw["cnf"] = cnf
+}
+
+// CertificateThumbprint returns the base64url encoded SHA-256 hash of the DER encoding of a
+// certificate, the "x5t#S256" confirmation member of certificate-bound access tokens.
+func CertificateThumbprint(cert *x509.Certificate) string {
+	return base64.RawURLEncoding.EncodeToString(digest(crypto.SHA256, cert.Raw))
+}
+
+// ValidateCertificateBinding returns a validator which checks that the token is bound to the
+// certificate with the "x5t#S256" member of its "cnf" claim (RFC 8705 section 3).
+func ValidateCertificateBinding(cert *x509.Certificate) Validator {
+	return func(token *JwtToken) error {
+		cnf, err := token.Claims.Confirmation()
+		if err != nil {
+			return err
+		}
+		if cnf == nil {
+			return &MissingClaimError{"cnf"}
+		}
+		if cert == nil || cnf.X5tS256 == "" ||
+			subtle.ConstantTimeCompare([]byte(cnf.X5tS256), []byte(CertificateThumbprint(cert))) != 1 {
+			return ErrCertificateBinding
+		}
+		return nil
+	}
+}
+
+// ValidateTLSClientBinding returns a validator which checks that the token is bound to the TLS
+// client certificate of the request. Servers must request client certificates, e.g. with the
+// tls.RequestClientCert client authentication policy.
+func ValidateTLSClientBinding(r *http.Request) Validator {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return func(token *JwtToken) error {
+			return ErrNoClientCertificate
+		}
+	}
+	return ValidateCertificateBinding(r.TLS.PeerCertificates[0])
+}
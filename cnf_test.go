@@ -0,0 +1,140 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func clientCertificate(t *testing.T) tls.Certificate {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: cert}
+}
+
+func TestConfirmation(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	key, _ := GenerateKey(JWT_ES256)
+	claims := &Claims{Expires: time.Now().Add(time.Hour).Unix()}
+	claims.SetConfirmation(&Confirmation{JWK: key.PublicJWK, Kid: "key1", JKT: "jkt", X5tS256: "x5t"})
+	raw, _ := Create(claims, hs256)
+	token, err := Parse(raw, hs256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cnf, err := token.Claims.Confirmation()
+	if err != nil || cnf.JWK.X != key.PublicJWK.X || cnf.Kid != "key1" || cnf.JKT != "jkt" || cnf.X5tS256 != "x5t" {
+		t.Log(cnf, err)
+		t.Fail()
+	}
+	if _, ok := token.Claims.Raw["cnf"].(map[string]interface{})["x5t#S256"]; !ok {
+		t.Log(token.Claims.Raw)
+		t.Fail()
+	}
+	if cnf, err := (&Claims{}).Confirmation(); cnf != nil || err != nil {
+		t.Fail()
+	}
+	if _, err := (&Claims{Raw: map[string]interface{}{"cnf": "jkt"}}).Confirmation(); err == nil {
+		t.Fail()
+	}
+}
+
+func TestCertificateBinding(t *testing.T) {
+	cert := clientCertificate(t)
+	other := clientCertificate(t)
+	hs256, _ := NewHS256(testSecret("secret"))
+	claims := &Claims{Expires: time.Now().Add(time.Hour).Unix()}
+	claims.SetConfirmation(&Confirmation{X5tS256: CertificateThumbprint(cert.Leaf)})
+	raw, _ := Create(claims, hs256)
+
+	m := NewMiddleware(hs256)
+	m.CertificateBound = true
+	server := httptest.NewUnstartedServer(m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	for _, test := range []struct {
+		certificates []tls.Certificate
+		token        string
+		status       int
+	}{
+		{[]tls.Certificate{cert}, raw, http.StatusOK},
+		{[]tls.Certificate{other}, raw, http.StatusUnauthorized},
+		{nil, raw, http.StatusUnauthorized},
+	} {
+		client := server.Client()
+		// the client certificate is only sent when a new connection is established
+		client.CloseIdleConnections()
+		client.Transport.(*http.Transport).TLSClientConfig.Certificates = test.certificates
+		r, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		r.Header.Set("Authorization", "Bearer "+test.token)
+		response, err := client.Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.StatusCode != test.status {
+			t.Log(response.StatusCode, response.Header.Get("WWW-Authenticate"))
+			t.Fail()
+		}
+	}
+
+	token, _ := Parse(raw, hs256)
+	if err := token.Validate(ValidateCertificateBinding(other.Leaf)); err != ErrCertificateBinding {
+		t.Log(err)
+		t.Fail()
+	}
+	unbound, _ := Parse(raw, hs256)
+	unbound.Claims.Raw = nil
+	if err := unbound.Validate(ValidateCertificateBinding(cert.Leaf)); err == nil {
+		t.Fail()
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if err := token.Validate(ValidateTLSClientBinding(r)); err != ErrNoClientCertificate {
+		t.Log(err)
+		t.Fail()
+	}
+}
@@ -0,0 +1,92 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestConcurrentAlgorithms shares every algorithm between goroutines. Run with -race.
+func TestConcurrentAlgorithms(t *testing.T) {
+	for _, c := range benchmarkCases(t) {
+		shared := benchmarkToken(t, c.algorithm)
+		tampered := []byte(shared)
+		tampered[strings.LastIndexByte(shared, '.')+1] ^= 'A' ^ 'B'
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 5; j++ {
+					token, err := Create(&Claims{Subject: c.name, Expires: time.Now().Add(time.Hour).Unix()}, c.algorithm)
+					if err != nil {
+						t.Error(c.name, err)
+						return
+					}
+					for _, raw := range []string{token, shared} {
+						if _, err := Parse(raw, c.algorithm); err != nil {
+							t.Error(c.name, err)
+							return
+						}
+					}
+					if _, err := Parse(string(tampered), c.algorithm); err == nil {
+						t.Error(c.name, "tampered token accepted")
+						return
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+	}
+}
+
+func TestConcurrentKeySet(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	key, _ := NewKey("k1", hs256)
+	keys := NewKeySet(key)
+	token := benchmarkToken(t, key)
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if i == 0 {
+					rotated, _ := NewKey("k2", hs256)
+					keys.Add(rotated)
+					keys.Remove("k2")
+					continue
+				}
+				if _, err := ParseWithKeySet(token, keys); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,171 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"crypto"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+// forge creates a token with an arbitrary header, signed with the algorithm if not nil.
+func forge(header string, claims *Claims, alg Algorithm) string {
+	payload, _ := claims.MarshalJSON()
+	signingInput := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	if alg == nil {
+		return signingInput + "."
+	}
+	signature, _ := alg.Sign([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestConfusionAlgorithmNone(t *testing.T) {
+	rs256, _ := NewRS256(mustReadFixture("rsa"))
+	key, _ := NewKey("key1", rs256)
+	claims := &Claims{Expires: time.Now().Add(time.Hour).Unix()}
+	for _, alg := range []string{"none", "None", "NONE", "nOnE"} {
+		token := forge(`{"alg":"`+alg+`","typ":"JWT"}`, claims, nil)
+		_, err := Parse(token, rs256)
+		if err != ErrAlgorithmNone {
+			t.Log(alg, err)
+			t.Fail()
+		}
+		_, err = ParseWithKeySet(token, NewKeySet(key))
+		if err != ErrAlgorithmNone {
+			t.Log(alg, err)
+			t.Fail()
+		}
+	}
+}
+
+func TestConfusionUnknownAlgorithm(t *testing.T) {
+	rs256, _ := NewRS256(mustReadFixture("rsa"))
+	token := forge(`{"alg":"RS1","typ":"JWT"}`, &Claims{}, rs256)
+	_, err := Parse(token, rs256)
+	if err != ErrUnknownAlgorithm {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+// TestConfusionPublicKeyAsHMACSecret covers the classic attack where the public RSA key of the
+// verifier is used as HS256 secret and the verifier trusts the "alg" header.
+func TestConfusionPublicKeyAsHMACSecret(t *testing.T) {
+	publicKey := mustReadFixture("rsa.pub")
+	_, err := NewHS256(publicKey)
+	if err == nil {
+		t.Log(err)
+		t.Fail()
+	}
+	_, err = NewHS256([]byte(strings.TrimSpace(string(publicKey))))
+	if err == nil {
+		t.Log(err)
+		t.Fail()
+	}
+
+	rs256, _ := NewRS256(mustReadFixture("rsa"))
+	token := forge(`{"alg":"HS256","typ":"JWT"}`, &Claims{}, &HS256{&HMAC{hash: crypto.SHA256, secret: publicKey, name: JWT_HS256}})
+	_, err = Parse(token, rs256)
+	if err != ErrInvalidAlgorithm {
+		t.Log(err)
+		t.Fail()
+	}
+	key, _ := NewKey("key1", rs256)
+	_, err = ParseWithKeySet(forge(`{"alg":"HS256","typ":"JWT","kid":"key1"}`, &Claims{}, nil), NewKeySet(key))
+	if err != ErrKeyNotFound {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+// TestConfusionHMACWithoutSecret makes sure HMAC signatures depend on the secret.
+func TestConfusionHMACWithoutSecret(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	other, _ := NewHS256(testSecret("other"))
+	token, _ := Create(&Claims{}, other)
+	_, err := Parse(token, hs256)
+	if err != ErrInvalidSignature {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestConfusionAllowList(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	rs256, _ := NewRS256(mustReadFixture("rsa"))
+	key1, _ := NewKey("key1", hs256)
+	key2, _ := NewKey("key2", rs256)
+	set := NewKeySet(key1, key2)
+	set.AllowAlgorithms(JWT_RS256)
+
+	token, _ := Create(&Claims{}, key1)
+	_, err := ParseWithKeySet(token, set)
+	if err != ErrAlgorithmNotAllowed {
+		t.Log(err)
+		t.Fail()
+	}
+	token, _ = Create(&Claims{}, key2)
+	_, err = ParseWithKeySet(token, set)
+	if err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+	set.AllowAlgorithms()
+	token, _ = Create(&Claims{}, key1)
+	_, err = ParseWithKeySet(token, set)
+	if err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+// TestConfusionKeyIDReuse makes sure a key is not used for a different algorithm just because
+// the "kid" header matches.
+func TestConfusionKeyIDReuse(t *testing.T) {
+	rs256, _ := NewRS256(mustReadFixture("rsa"))
+	rs512, _ := NewRS512(mustReadFixture("rsa"))
+	key, _ := NewKey("key1", rs256)
+	token := forge(`{"alg":"RS512","typ":"JWT","kid":"key1"}`, &Claims{}, rs512)
+	_, err := ParseWithKeySet(token, NewKeySet(key))
+	if err != ErrKeyNotFound {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func mustReadFixture(file string) []byte {
+	data, err := readFixture(file)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// testSecret returns a 64 byte HMAC secret derived from seed, which is long enough for
+// HS256, HS384 and HS512.
+func testSecret(seed string) []byte {
+	return []byte(strings.Repeat(seed, 64/len(seed)+1)[:64])
+}
@@ -0,0 +1,166 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	ErrInvalidCriticalHeader     = errors.New("Invalid crit header")
+	ErrUnsupportedCriticalHeader = errors.New("Token contains an unsupported critical header")
+)
+
+// registeredHeaders are the header parameters defined by JWS, JWE and JWA. They must not be
+// listed in "crit" (RFC 7515 section 4.1.11).
+var registeredHeaders = []string{
+	"alg", "jku", "jwk", "kid", "x5u", "x5c", "x5t", "x5t#S256", "typ", "cty", "crit",
+	"enc", "zip", "epk", "apu", "apv", "iv", "tag", "p2s", "p2c",
+}
+
+// managedHeaders are set by Create and can't be overridden with WithHeader.
+var managedHeaders = []string{"alg", "typ", "kid", "crit"}
+
+// CriticalHeaderHandler validates the value of a critical header parameter of a verified token.
+type CriticalHeaderHandler func(value interface{}) error
+
+// CriticalHeaders is a registry of the critical header extensions an application understands.
+// Tokens listing other extensions in their "crit" header are rejected. A CriticalHeaders
+// registry may be used from multiple goroutines.
+type CriticalHeaders struct {
+	mutex    sync.RWMutex
+	handlers map[string]CriticalHeaderHandler
+}
+
+// NewCriticalHeaders creates a new, empty registry.
+func NewCriticalHeaders() *CriticalHeaders {
+	return &CriticalHeaders{handlers: map[string]CriticalHeaderHandler{}}
+}
+
+// Register declares a header extension as understood. The handler is called with the value
+// of the header parameter after the signature has been verified and may be nil.
+func (c *CriticalHeaders) Register(name string, handler CriticalHeaderHandler) error {
+	if contains(registeredHeaders, name) {
+		return errors.New("Registered header parameters can't be critical: " + name)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.handlers[name] = handler
+	return nil
+}
+
+func (c *CriticalHeaders) handler(name string) (CriticalHeaderHandler, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	handler, ok := c.handlers[name]
+	return handler, ok
+}
+
+// WithCriticalHeaders sets the critical header extensions understood by Parse.
+func WithCriticalHeaders(registry *CriticalHeaders) Option {
+	return func(o *options) {
+		o.critical = registry
+	}
+}
+
+// WithHeader adds a header parameter to tokens created with Create.
+func WithHeader(name string, value interface{}) Option {
+	return func(o *options) {
+		if o.headers == nil {
+			o.headers = map[string]interface{}{}
+		}
+		o.headers[name] = value
+	}
+}
+
+// WithCriticalHeader adds a header parameter to tokens created with Create and lists it in
+// the "crit" header. Recipients must understand the parameter to accept the token.
+func WithCriticalHeader(name string, value interface{}) Option {
+	return func(o *options) {
+		WithHeader(name, value)(o)
+		if !contains(o.crit, name) {
+			o.crit = append(o.crit, name)
+		}
+	}
+}
+
+// applyHeaders adds the custom header parameters to a header created by Create.
+func (o *options) applyHeaders(header *JwtHeader) error {
+	for name, value := range o.headers {
+		if contains(managedHeaders, name) {
+			return errors.New("Header parameter can't be overridden: " + name)
+		}
+		if header.Raw == nil {
+			header.Raw = map[string]interface{}{}
+		}
+		header.Raw[name] = value
+	}
+	for _, name := range o.crit {
+		if contains(registeredHeaders, name) {
+			return errors.New("Registered header parameters can't be critical: " + name)
+		}
+	}
+	header.Crit = o.crit
+	return nil
+}
+
+// checkCritical checks that all critical header parameters are present and understood.
+func (o *options) checkCritical(header *JwtHeader) error {
+	if _, ok := header.Raw["crit"]; !ok {
+		return nil
+	}
+	if len(header.Crit) == 0 {
+		return ErrInvalidCriticalHeader
+	}
+	for i, name := range header.Crit {
+		if contains(registeredHeaders, name) || contains(header.Crit[:i], name) {
+			return ErrInvalidCriticalHeader
+		}
+		if _, ok := header.Raw[name]; !ok {
+			return ErrInvalidCriticalHeader
+		}
+		if _, ok := o.critical.handler(name); !ok {
+			return ErrUnsupportedCriticalHeader
+		}
+	}
+	return nil
+}
+
+// handleCritical runs the handlers of the critical header parameters.
+func (o *options) handleCritical(header *JwtHeader) error {
+	for _, name := range header.Crit {
+		handler, _ := o.critical.handler(name)
+		if handler == nil {
+			continue
+		}
+		if err := handler(header.Raw[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
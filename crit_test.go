@@ -0,0 +1,133 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCriticalHeader(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	token, err := Create(&Claims{}, hs256, WithCriticalHeader("exp-version", "2"), WithHeader("x-trace", "abc"))
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	unverified, _ := Decode(token)
+	if len(unverified.Header.Crit) != 1 || unverified.Header.Raw["exp-version"] != "2" || unverified.Header.Raw["x-trace"] != "abc" {
+		t.Log(unverified.Header)
+		t.Fail()
+	}
+
+	_, err = Parse(token, hs256)
+	if err != ErrUnsupportedCriticalHeader {
+		t.Log(err)
+		t.Fail()
+	}
+
+	var seen interface{}
+	registry := NewCriticalHeaders()
+	registry.Register("exp-version", func(value interface{}) error {
+		seen = value
+		return nil
+	})
+	parsed, err := Parse(token, hs256, WithCriticalHeaders(registry))
+	if err != nil || seen != "2" || parsed.Header.Crit[0] != "exp-version" {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestCriticalHeaderHandlerError(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	token, _ := Create(&Claims{}, hs256, WithCriticalHeader("exp-version", "3"))
+	registry := NewCriticalHeaders()
+	unsupported := errors.New("unsupported version")
+	registry.Register("exp-version", func(value interface{}) error { return unsupported })
+	_, err := Parse(token, hs256, WithCriticalHeaders(registry))
+	if err != unsupported {
+		t.Log(err)
+		t.Fail()
+	}
+	key, _ := NewKey("key1", hs256)
+	token, _ = Create(&Claims{}, key, WithCriticalHeader("exp-version", "3"))
+	_, err = ParseWithKeySet(token, NewKeySet(key), WithCriticalHeaders(registry))
+	if err != unsupported {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestCriticalHeaderInvalid(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	registry := NewCriticalHeaders()
+	registry.Register("b64", nil)
+	for _, header := range []string{
+		`{"alg":"HS256","crit":[]}`,
+		`{"alg":"HS256","crit":"b64","b64":false}`,
+		`{"alg":"HS256","crit":["b64"]}`,
+		`{"alg":"HS256","crit":["alg"]}`,
+		`{"alg":"HS256","crit":["b64","b64"],"b64":false}`,
+	} {
+		_, err := Parse(forge(header, &Claims{}, hs256), hs256, WithCriticalHeaders(registry))
+		if err == nil {
+			t.Log(header)
+			t.Fail()
+		}
+	}
+	_, err := Parse(forge(`{"alg":"HS256","crit":["b64"],"b64":false}`, &Claims{}, hs256), hs256, WithCriticalHeaders(registry))
+	if err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestCriticalHeaderCreateInvalid(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	_, err := Create(&Claims{}, hs256, WithCriticalHeader("kid", "key1"))
+	if err == nil {
+		t.Fail()
+	}
+	_, err = Create(&Claims{}, hs256, WithCriticalHeader("cty", "JWT"))
+	if err == nil {
+		t.Fail()
+	}
+	_, err = Create(&Claims{}, hs256, WithHeader("alg", "none"))
+	if err == nil {
+		t.Fail()
+	}
+	err = NewCriticalHeaders().Register("alg", nil)
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestHeaderCreateUnencodable(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	_, err := Create(&Claims{}, hs256, WithHeader("x5c", make(chan int)))
+	if err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,269 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// UnverifiedToken is a decoded token whose signature has not been verified. Its header and
+// claims can be used to select a verifier, but must not be trusted.
+type UnverifiedToken struct {
+	Header JwtHeader
+	Claims Claims
+
+	header JwtHeader
+	token  string
+	// positions of the dots separating header, payload and signature
+	headerEnd  int
+	payloadEnd int
+	// exported is set if the token was returned by Decode and may be verified more than once
+	exported bool
+}
+
+// bufferPool holds buffers for decoding token segments.
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		buffer := make([]byte, 0, 1024)
+		return &buffer
+	},
+}
+
+func getBuffer(size int) *[]byte {
+	buffer := bufferPool.Get().(*[]byte)
+	if cap(*buffer) < size {
+		*buffer = make([]byte, size)
+	}
+	*buffer = (*buffer)[:size]
+	return buffer
+}
+
+func putBuffer(buffer *[]byte) {
+	if cap(*buffer) <= 64*1024 {
+		bufferPool.Put(buffer)
+	}
+}
+
+// decodeInto decodes a base64url segment into a pooled buffer. The buffer must be returned
+// with putBuffer.
+func decodeInto(segment string, max int) (*[]byte, error) {
+	size := base64URL.DecodedLen(len(segment))
+	if max > 0 && size > max {
+		return nil, ErrTokenTooLarge
+	}
+	src := getBuffer(len(segment))
+	defer putBuffer(src)
+	copy(*src, segment)
+	buffer := getBuffer(size)
+	n, err := base64URL.Decode(*buffer, *src)
+	if err != nil {
+		putBuffer(buffer)
+		return nil, err
+	}
+	*buffer = (*buffer)[:n]
+	return buffer, nil
+}
+
+// decodeJSON decodes a base64url segment holding a JSON object with parseObject.
+func decodeJSON(segment string, max int, maxDepth int) (map[string]interface{}, error) {
+	buffer, err := decodeInto(segment, max)
+	if err != nil {
+		return nil, err
+	}
+	defer putBuffer(buffer)
+	return parseObject(*buffer, maxDepth)
+}
+
+// Decode decodes a JWT token from a string without verifying it. Use Verify or
+// VerifyWithKeySet on the result to obtain a verified token from the same bytes.
+func Decode(token string, opts ...Option) (*UnverifiedToken, error) {
+	return decode(token, true, newOptions(opts))
+}
+
+// ParseUnverified is an alias for Decode.
+func ParseUnverified(token string, opts ...Option) (*UnverifiedToken, error) {
+	return Decode(token, opts...)
+}
+
+// decode splits a token and decodes its header. Encodings must be canonical, header and
+// payload must be JSON objects without duplicate member names and all parts must be within
+// the limits. The payload and signature are only decoded if withClaims is set, otherwise
+// they are decoded by Verify.
+func decode(token string, withClaims bool, o *options) (*UnverifiedToken, error) {
+	limits := o.limits
+	if limits.MaxTokenLength > 0 && len(token) > limits.MaxTokenLength {
+		return nil, ErrTokenTooLarge
+	}
+	headerEnd := strings.IndexByte(token, '.')
+	if headerEnd < 0 {
+		return nil, ErrInvalidTokenFormat
+	}
+	payloadEnd := strings.IndexByte(token[headerEnd+1:], '.')
+	if payloadEnd < 0 {
+		return nil, ErrInvalidTokenFormat
+	}
+	payloadEnd += headerEnd + 1
+	if strings.IndexByte(token[payloadEnd+1:], '.') >= 0 {
+		return nil, ErrInvalidTokenFormat
+	}
+	rawHeader, err := decodeJSON(token[:headerEnd], limits.MaxHeaderSize, limits.MaxDepth)
+	if err != nil {
+		return nil, err
+	}
+	header, err := headerFromMap(rawHeader)
+	if err != nil {
+		return nil, err
+	}
+	unverified := &UnverifiedToken{
+		header:     header,
+		token:      token,
+		headerEnd:  headerEnd,
+		payloadEnd: payloadEnd,
+	}
+	if !withClaims {
+		return unverified, nil
+	}
+	// the exported header is a copy, so Verify is not affected by changes to it
+	unverified.Header = header.copy()
+	unverified.exported = true
+	rawClaims, err := decodeJSON(token[headerEnd+1:payloadEnd], limits.MaxPayloadSize, limits.MaxDepth)
+	if err != nil {
+		return nil, err
+	}
+	if unverified.Claims, err = claimsFromMap(rawClaims); err != nil {
+		return nil, err
+	}
+	signature, err := decodeInto(token[payloadEnd+1:], 0)
+	if err != nil {
+		return nil, err
+	}
+	putBuffer(signature)
+	return unverified, nil
+}
+
+// Verify verifies the token with the algorithm. The returned token is decoded again from
+// the verified bytes, so changes to the exported fields of the unverified token have no effect.
+func (u *UnverifiedToken) Verify(alg Algorithm, opts ...Option) (*JwtToken, error) {
+	return u.verify(alg, newOptions(opts))
+}
+
+func (u *UnverifiedToken) verify(alg Algorithm, o *options) (*JwtToken, error) {
+	if alg == nil {
+		return nil, errors.New("Algorithm can't be nil")
+	}
+	if err := checkUnsecured(alg, o); err != nil {
+		return nil, err
+	}
+	if u.header.Alg != JWT_NONE || !o.allowUnsecured {
+		if err := checkAlgorithm(u.header.Alg); err != nil {
+			return nil, err
+		}
+	}
+	if u.header.Alg != alg.Name() {
+		return nil, ErrInvalidAlgorithm
+	}
+	if err := o.checkCritical(&u.header); err != nil {
+		return nil, err
+	}
+	signature, err := decodeInto(u.token[u.payloadEnd+1:], 0)
+	if err != nil {
+		return nil, err
+	}
+	defer putBuffer(signature)
+	signingInput := getBuffer(u.payloadEnd)
+	defer putBuffer(signingInput)
+	copy(*signingInput, u.token)
+	if err := alg.Verify(*signingInput, *signature); err != nil {
+		return nil, ErrInvalidSignature
+	}
+	if err := o.handleCritical(&u.header); err != nil {
+		return nil, err
+	}
+	rawClaims, err := decodeJSON(u.token[u.headerEnd+1:u.payloadEnd], o.limits.MaxPayloadSize, o.limits.MaxDepth)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := claimsFromMap(rawClaims)
+	if err != nil {
+		return nil, err
+	}
+	header := u.header
+	if u.exported {
+		header = header.copy()
+	}
+	return &JwtToken{header, claims, append([]byte{}, *signature...)}, nil
+}
+
+// checkAlgorithm rejects unsecured and unknown algorithms.
+func checkAlgorithm(name string) error {
+	if strings.EqualFold(name, "none") {
+		return ErrAlgorithmNone
+	}
+	for _, accepted := range algorithms {
+		if name == accepted {
+			return nil
+		}
+	}
+	return ErrUnknownAlgorithm
+}
+
+// checkUnsecured rejects the unsecured algorithm unless explicitly allowed. Only the
+// Unsecured type may use the name "none".
+func checkUnsecured(alg Algorithm, o *options) error {
+	if !strings.EqualFold(alg.Name(), JWT_NONE) {
+		return nil
+	}
+	if _, ok := alg.(*Unsecured); !ok || !o.allowUnsecured {
+		return ErrAlgorithmNone
+	}
+	return nil
+}
+
+// VerifyWithKeySet verifies the token with the matching key of the set. Unsecured tokens
+// are never accepted.
+func (u *UnverifiedToken) VerifyWithKeySet(keys *KeySet, opts ...Option) (*JwtToken, error) {
+	if keys == nil {
+		return nil, errors.New("Key set can't be nil")
+	}
+	token, _, err := u.verifyWithKeySet(keys, newOptions(opts))
+	return token, err
+}
+
+// verifyWithKeySet verifies the token and returns the key it was verified with.
+func (u *UnverifiedToken) verifyWithKeySet(keys *KeySet, o *options) (*JwtToken, *Key, error) {
+	if err := checkAlgorithm(u.header.Alg); err != nil {
+		return nil, nil, err
+	}
+	key, err := keys.Lookup(&u.header)
+	if err != nil {
+		return nil, nil, err
+	}
+	token, err := u.verify(key, o)
+	if err != nil {
+		return nil, nil, err
+	}
+	return token, key, nil
+}
@@ -0,0 +1,102 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecode(t *testing.T) {
+	alg, _ := NewHS256(testSecret("secret"))
+	key, _ := NewKey("key1", alg)
+	tokenString, _ := Create(&Claims{Issuer: "issuer", Expires: time.Now().Add(time.Hour).Unix()}, key)
+	unverified, err := Decode(tokenString)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if unverified.Header.Kid != "key1" || unverified.Claims.Issuer != "issuer" {
+		t.Log(unverified)
+		t.Fail()
+	}
+	token, err := unverified.Verify(alg)
+	if err != nil || token.Claims.Issuer != "issuer" {
+		t.Log(err)
+		t.Fail()
+	}
+	token, err = unverified.VerifyWithKeySet(NewKeySet(key))
+	if err != nil || token.Header.Kid != "key1" {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestDecodeModifiedToken(t *testing.T) {
+	alg, _ := NewHS256(testSecret("secret"))
+	tokenString, _ := Create(&Claims{Issuer: "issuer"}, alg)
+	unverified, _ := ParseUnverified(tokenString)
+	unverified.Header.Alg = JWT_HS512
+	unverified.Claims.Issuer = "evil"
+	token, err := unverified.Verify(alg)
+	if err != nil || token.Claims.Issuer != "issuer" || token.Header.Alg != JWT_HS256 {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestDecodeInvalidSignature(t *testing.T) {
+	alg, _ := NewHS256(testSecret("secret"))
+	tokenString, _ := Create(&Claims{Issuer: "issuer"}, alg)
+	other, _ := Create(&Claims{Issuer: "other"}, alg)
+	tampered := tokenString[:len(tokenString)-43] + other[len(other)-43:]
+	unverified, err := Decode(tampered)
+	if err != nil || unverified.Claims.Issuer != "issuer" {
+		t.Log(err)
+		t.FailNow()
+	}
+	_, err = unverified.Verify(alg)
+	if err != ErrInvalidSignature {
+		t.Log(err)
+		t.Fail()
+	}
+	_, err = unverified.Verify(nil)
+	if err == nil {
+		t.Fail()
+	}
+	_, err = unverified.VerifyWithKeySet(nil)
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestDecodeInvalidToken(t *testing.T) {
+	for _, token := range []string{"", "a.b", "!.a.a", "e30.!.a", "e30.e30.!", "e30.YQ.", "YQ.e30."} {
+		_, err := Decode(token)
+		if err == nil {
+			t.Log(token)
+			t.Fail()
+		}
+	}
+}
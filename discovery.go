@@ -0,0 +1,157 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DISCOVERY_PATH is the path of the discovery document relative to the issuer
+// (OpenID Connect Discovery 1.0 section 4).
+const DISCOVERY_PATH = "/.well-known/openid-configuration"
+
+// DISCOVERY_MAX_SIZE limits the size of discovery documents and JWK sets.
+const DISCOVERY_MAX_SIZE = 1024 * 1024
+
+var ErrIssuerMismatch = errors.New("Issuer of the discovery document does not match")
+
+// ProviderMetadata is the discovery document of an OpenID provider
+// (OpenID Connect Discovery 1.0 section 3).
+type ProviderMetadata struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint,omitempty"`
+	TokenEndpoint                     string   `json:"token_endpoint,omitempty"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint,omitempty"`
+	JwksURI                           string   `json:"jwks_uri"`
+	RegistrationEndpoint              string   `json:"registration_endpoint,omitempty"`
+	ScopesSupported                   []string `json:"scopes_supported,omitempty"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported,omitempty"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported,omitempty"`
+	ClaimsSupported                   []string `json:"claims_supported,omitempty"`
+}
+
+// NewProviderMetadata creates the discovery document for an issuer which publishes its keys
+// at jwksURI and signs ID tokens with the algorithms. The other endpoints can be set on the
+// result.
+func NewProviderMetadata(issuer, jwksURI string, algorithms ...string) *ProviderMetadata {
+	return &ProviderMetadata{
+		Issuer:                           issuer,
+		JwksURI:                          jwksURI,
+		ResponseTypesSupported:           []string{"code", "id_token", "id_token token"},
+		SubjectTypesSupported:            []string{"public"},
+		IDTokenSigningAlgValuesSupported: append([]string{}, algorithms...),
+	}
+}
+
+// ServeHTTP serves the discovery document. It is meant to be registered at DISCOVERY_PATH.
+func (m *ProviderMetadata) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m)
+}
+
+// Provider is an OpenID provider configured from its discovery document. Its key set holds
+// the keys of the JWK set at the jwks_uri and accepts the algorithms of
+// id_token_signing_alg_values_supported.
+type Provider struct {
+	Metadata *ProviderMetadata
+	KeySet   *KeySet
+	client   *http.Client
+}
+
+// Discover loads the discovery document of the issuer and the JWK set it refers to. The
+// issuer of the document must match the issuer exactly. If client is nil,
+// http.DefaultClient is used.
+func Discover(ctx context.Context, issuer string, client *http.Client) (*Provider, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	metadata := &ProviderMetadata{}
+	if err := fetchJSON(ctx, client, strings.TrimSuffix(issuer, "/")+DISCOVERY_PATH, metadata); err != nil {
+		return nil, err
+	}
+	if metadata.Issuer != issuer {
+		return nil, ErrIssuerMismatch
+	}
+	if metadata.JwksURI == "" {
+		return nil, errors.New("Discovery document has no jwks_uri")
+	}
+	provider := &Provider{Metadata: metadata, KeySet: NewKeySet(), client: client}
+	provider.KeySet.AllowAlgorithms(metadata.IDTokenSigningAlgValuesSupported...)
+	if err := provider.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+// Refresh reloads the JWK set and replaces the keys of the key set, e.g. after
+// ErrKeyNotFound was returned for a token signed with a new key.
+func (p *Provider) Refresh(ctx context.Context) error {
+	jwks := &JWKS{}
+	if err := fetchJSON(ctx, p.client, p.Metadata.JwksURI, jwks); err != nil {
+		return err
+	}
+	keys, err := jwks.keys(p.Metadata.IDTokenSigningAlgValuesSupported)
+	if err != nil {
+		return err
+	}
+	p.KeySet.Replace(keys...)
+	return nil
+}
+
+// Verifier returns an ID token verifier for the client which uses the key set of the provider.
+func (p *Provider) Verifier(clientID string) *IDTokenVerifier {
+	return &IDTokenVerifier{Issuer: p.Metadata.Issuer, ClientID: clientID, KeySet: p.KeySet}
+}
+
+func fetchJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return errors.New("Unexpected status of " + url + ": " + response.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(response.Body, DISCOVERY_MAX_SIZE+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > DISCOVERY_MAX_SIZE {
+		return errors.New("Response of " + url + " is too large")
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,181 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type testProvider struct {
+	*httptest.Server
+	metadata *ProviderMetadata
+	jwks     *JWKS
+}
+
+func newTestProvider(t *testing.T, algorithms ...string) *testProvider {
+	provider := &testProvider{jwks: &JWKS{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc(DISCOVERY_PATH, func(w http.ResponseWriter, r *http.Request) {
+		provider.metadata.ServeHTTP(w, r)
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		provider.jwks.ServeHTTP(w, r)
+	})
+	provider.Server = httptest.NewServer(mux)
+	t.Cleanup(provider.Close)
+	provider.metadata = NewProviderMetadata(provider.URL, provider.URL+"/jwks", algorithms...)
+	return provider
+}
+
+// addKey generates a key, publishes it and returns an algorithm signing with it.
+func (p *testProvider) addKey(t *testing.T, name, kid string) *Key {
+	generated, err := GenerateKey(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated.PrivateJWK.Kid = kid
+	p.jwks.Keys = append(p.jwks.Keys, generated.PrivateJWK)
+	return &Key{generated.Algorithm, kid}
+}
+
+func (p *testProvider) idToken(t *testing.T, key *Key) string {
+	token, err := Create(&Claims{
+		Issuer:   p.URL,
+		Subject:  "user",
+		Audience: oidcClientID,
+		Expires:  time.Now().Add(time.Hour).Unix(),
+	}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestDiscover(t *testing.T) {
+	p := newTestProvider(t, JWT_RS256, JWT_ES256)
+	rs256 := p.addKey(t, JWT_RS256, "rsa")
+	es256 := p.addKey(t, JWT_ES256, "ec")
+	provider, err := Discover(context.Background(), p.URL, p.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if provider.Metadata.JwksURI != p.URL+"/jwks" || len(provider.KeySet.Keys()) != 2 {
+		t.Log(provider.Metadata, provider.KeySet.Keys())
+		t.Fail()
+	}
+	verifier := provider.Verifier(oidcClientID)
+	for _, key := range []*Key{rs256, es256} {
+		if _, err := verifier.Verify(p.idToken(t, key)); err != nil {
+			t.Log(key.ID, err)
+			t.Fail()
+		}
+	}
+}
+
+func TestDiscoverAlgorithms(t *testing.T) {
+	p := newTestProvider(t, JWT_RS256)
+	p.addKey(t, JWT_RS256, "rsa")
+	es256 := p.addKey(t, JWT_ES256, "ec")
+	provider, err := Discover(context.Background(), p.URL, p.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = provider.Verifier(oidcClientID).Verify(p.idToken(t, es256))
+	if err != ErrAlgorithmNotAllowed {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestDiscoverRefresh(t *testing.T) {
+	p := newTestProvider(t, JWT_EdDSA)
+	p.addKey(t, JWT_EdDSA, "old")
+	provider, err := Discover(context.Background(), p.URL, p.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated := p.addKey(t, JWT_EdDSA, "new")
+	p.jwks.Keys = p.jwks.Keys[1:]
+	verifier := provider.Verifier(oidcClientID)
+	token := p.idToken(t, rotated)
+	if _, err := verifier.Verify(token); err != ErrKeyNotFound {
+		t.Log(err)
+		t.Fail()
+	}
+	if err := provider.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.Verify(token); err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+	if keys := provider.KeySet.Keys(); len(keys) != 1 || keys[0].ID != "new" {
+		t.Log(keys)
+		t.Fail()
+	}
+}
+
+func TestDiscoverErrors(t *testing.T) {
+	p := newTestProvider(t, JWT_RS256)
+	p.addKey(t, JWT_RS256, "rsa")
+	if _, err := Discover(context.Background(), p.URL+"/", p.Client()); err != ErrIssuerMismatch {
+		t.Log(err)
+		t.Fail()
+	}
+	if _, err := Discover(context.Background(), p.URL+"/other", p.Client()); err == nil {
+		t.Fail()
+	}
+	p.metadata.JwksURI = p.URL + "/missing"
+	if _, err := Discover(context.Background(), p.URL, p.Client()); err == nil {
+		t.Fail()
+	}
+	p.metadata.JwksURI = ""
+	if _, err := Discover(context.Background(), p.URL, p.Client()); err == nil {
+		t.Fail()
+	}
+}
+
+func TestProviderMetadata(t *testing.T) {
+	metadata := NewProviderMetadata("https://issuer.example.com", "https://issuer.example.com/jwks", JWT_RS256)
+	w := httptest.NewRecorder()
+	metadata.ServeHTTP(w, httptest.NewRequest(http.MethodGet, DISCOVERY_PATH, nil))
+	var document map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &document); err != nil {
+		t.Fatal(err)
+	}
+	for _, member := range []string{"issuer", "jwks_uri", "response_types_supported", "subject_types_supported", "id_token_signing_alg_values_supported"} {
+		if _, ok := document[member]; !ok {
+			t.Log(member)
+			t.Fail()
+		}
+	}
+	if _, ok := document["token_endpoint"]; ok {
+		t.Fail()
+	}
+}
@@ -0,0 +1,449 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// TYP_DPOP_JWT is the "typ" header of DPoP proofs (RFC 9449 section 4.2).
+	TYP_DPOP_JWT = "dpop+jwt"
+	// DPOP_HEADER is the HTTP header carrying a DPoP proof.
+	DPOP_HEADER = "DPoP"
+	// DPOP_NONCE_HEADER is the HTTP header carrying a server provided nonce.
+	DPOP_NONCE_HEADER    = "DPoP-Nonce"
+	DPOP_DEFAULT_MAX_AGE = 5 * time.Minute
+)
+
+var (
+	ErrInvalidDPoPProof = errors.New("Invalid DPoP proof")
+	ErrDPoPReplay       = errors.New("DPoP proof has already been used")
+	ErrUseDPoPNonce     = errors.New("DPoP proof requires a valid nonce")
+	ErrDPoPBinding      = errors.New("Access token is not bound to the DPoP key")
+)
+
+// DPoPError is returned if a DPoP proof is malformed. It matches ErrInvalidDPoPProof with errors.Is.
+type DPoPError struct {
+	Reason string
+}
+
+func (e *DPoPError) Error() string {
+	return "Invalid DPoP proof: " + e.Reason
+}
+
+func (e *DPoPError) Is(target error) bool {
+	return target == ErrInvalidDPoPProof
+}
+
+// DPoPSigner creates DPoP proofs (RFC 9449 section 4) with a private key. The public key is
+// embedded in the "jwk" header of every proof.
+type DPoPSigner struct {
+	algorithm  Algorithm
+	jwk        *JWK
+	thumbprint string
+}
+
+// NewDPoPSigner creates a signer from a private asymmetric JWK. If name is empty, the "alg"
+// member of the key is used.
+func NewDPoPSigner(key *JWK, name string) (*DPoPSigner, error) {
+	if key == nil {
+		return nil, errors.New("Key can't be nil")
+	}
+	if key.Kty == "oct" || !key.IsPrivate() {
+		return nil, errors.New("DPoP proofs require a private asymmetric key")
+	}
+	algorithm, err := key.Algorithm(name)
+	if err != nil {
+		return nil, err
+	}
+	public := key.Public()
+	thumbprint, err := public.Thumbprint()
+	if err != nil {
+		return nil, err
+	}
+	return &DPoPSigner{algorithm: algorithm, jwk: public, thumbprint: thumbprint}, nil
+}
+
+// Thumbprint returns the JWK thumbprint of the key, the "jkt" value access tokens are bound to.
+func (s *DPoPSigner) Thumbprint() string {
+	return s.thumbprint
+}
+
+// Proof creates a proof for a request with the HTTP method and URI. The "ath" claim is added
+// if accessToken is not empty and the "nonce" claim if nonce is not empty.
+func (s *DPoPSigner) Proof(method, uri, accessToken, nonce string) (string, error) {
+	htu, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	htu.RawQuery, htu.Fragment, htu.RawFragment = "", "", ""
+	id, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+	claims := &Claims{Raw: map[string]interface{}{
+		"jti": id,
+		"htm": method,
+		"htu": htu.String(),
+	}}
+	if accessToken != "" {
+		claims.Raw["ath"] = accessTokenHash(accessToken)
+	}
+	if nonce != "" {
+		claims.Raw["nonce"] = nonce
+	}
+	return Create(claims, s.algorithm, WithType(TYP_DPOP_JWT), WithHeader("jwk", s.jwk))
+}
+
+// DPoPProof is a verified DPoP proof.
+type DPoPProof struct {
+	Token *JwtToken
+	// JWK is the public key of the proof.
+	JWK *JWK
+	// Thumbprint is the JWK thumbprint of the key, to be compared with the "cnf" claim
+	// of the access token.
+	Thumbprint string
+}
+
+// DPoPVerifier validates DPoP proofs on the server side (RFC 9449 section 4.3).
+type DPoPVerifier struct {
+	// Algorithms are the accepted signature algorithms. All asymmetric algorithms are
+	// accepted if empty.
+	Algorithms []string
+	// MaxAge is the maximum age of a proof based on its "iat" claim. DPOP_DEFAULT_MAX_AGE
+	// is used if zero.
+	MaxAge time.Duration
+	// Leeway accepts proofs issued slightly in the future because of clock skew.
+	Leeway time.Duration
+	// Replay detects proofs which have been used before. Replays are not detected if nil.
+	Replay ReplayCache
+	// Nonces requires proofs to carry a nonce provided by the server if not nil.
+	Nonces *DPoPNonces
+	// Options are applied when parsing the proof.
+	Options []Option
+
+	now func() time.Time
+}
+
+// NewDPoPVerifier creates a verifier with an in-memory replay cache.
+func NewDPoPVerifier() *DPoPVerifier {
+	return &DPoPVerifier{Replay: NewMemoryReplayCache()}
+}
+
+// Verify verifies a proof for a request with the HTTP method and URI. The URIs are compared
+// without query and fragment after normalization. If accessToken is not empty, the proof must
+// carry its hash in the "ath" claim. ErrUseDPoPNonce is returned if the verifier requires a
+// nonce and the proof does not carry a valid one; the current nonce should then be sent to
+// the client in the DPoP-Nonce header.
+func (v *DPoPVerifier) Verify(proof, method, uri, accessToken string) (*DPoPProof, error) {
+	unverified, err := Decode(proof, v.Options...)
+	if err != nil {
+		return nil, err
+	}
+	header := &unverified.Header
+	if !strings.EqualFold(header.Typ, TYP_DPOP_JWT) {
+		return nil, &DPoPError{"typ must be " + TYP_DPOP_JWT}
+	}
+	if err := checkAlgorithm(header.Alg); err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(header.Alg, "HS") {
+		return nil, &DPoPError{"symmetric algorithms are not allowed"}
+	}
+	if len(v.Algorithms) > 0 && !contains(v.Algorithms, header.Alg) {
+		return nil, ErrAlgorithmNotAllowed
+	}
+	jwk, err := headerJWK(header)
+	if err != nil {
+		return nil, err
+	}
+	alg, err := jwk.Algorithm(header.Alg)
+	if err != nil {
+		return nil, &DPoPError{err.Error()}
+	}
+	token, err := unverified.Verify(alg, v.Options...)
+	if err != nil {
+		return nil, err
+	}
+	claims := token.Claims.Raw
+	id, _ := claims["jti"].(string)
+	if id == "" {
+		return nil, &DPoPError{"jti is missing"}
+	}
+	if htm, _ := claims["htm"].(string); htm != method {
+		return nil, &DPoPError{"htm does not match"}
+	}
+	htu, _ := claims["htu"].(string)
+	if !sameHTU(htu, uri) {
+		return nil, &DPoPError{"htu does not match"}
+	}
+	now := time.Now()
+	if v.now != nil {
+		now = v.now()
+	}
+	maxAge := v.MaxAge
+	if maxAge == 0 {
+		maxAge = DPOP_DEFAULT_MAX_AGE
+	}
+	issued := time.Unix(token.Claims.IssuedAt, 0)
+	if token.Claims.IssuedAt == 0 || issued.After(now.Add(v.Leeway)) || issued.Before(now.Add(-maxAge-v.Leeway)) {
+		return nil, &DPoPError{"iat is missing or out of range"}
+	}
+	if accessToken != "" {
+		ath, _ := claims["ath"].(string)
+		if subtle.ConstantTimeCompare([]byte(ath), []byte(accessTokenHash(accessToken))) != 1 {
+			return nil, &DPoPError{"ath does not match"}
+		}
+	}
+	if v.Nonces != nil {
+		nonce, _ := claims["nonce"].(string)
+		valid, err := v.Nonces.Valid(nonce)
+		if err != nil {
+			return nil, err
+		}
+		if !valid {
+			return nil, ErrUseDPoPNonce
+		}
+	}
+	thumbprint, err := jwk.Thumbprint()
+	if err != nil {
+		return nil, err
+	}
+	if v.Replay != nil && v.Replay.Seen(thumbprint+"."+id, issued.Add(maxAge+v.Leeway)) {
+		return nil, ErrDPoPReplay
+	}
+	return &DPoPProof{Token: token, JWK: jwk, Thumbprint: thumbprint}, nil
+}
+
+// VerifyRequest verifies the proof in the DPoP header of a request. The URI is built from
+// the request, so servers behind a proxy which rewrites the scheme or host should use Verify.
+func (v *DPoPVerifier) VerifyRequest(r *http.Request, accessToken string) (*DPoPProof, error) {
+	proofs := r.Header.Values(DPOP_HEADER)
+	if len(proofs) != 1 {
+		return nil, &DPoPError{"request must carry exactly one proof"}
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return v.Verify(proofs[0], r.Method, scheme+"://"+r.Host+r.URL.EscapedPath(), accessToken)
+}
+
+// headerJWK reads the public key from the "jwk" header of a proof.
+func headerJWK(header *JwtHeader) (*JWK, error) {
+	raw, ok := header.Raw["jwk"].(map[string]interface{})
+	if !ok {
+		return nil, &DPoPError{"jwk header is missing"}
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	jwk := &JWK{}
+	if err := json.Unmarshal(data, jwk); err != nil {
+		return nil, &DPoPError{"jwk header is invalid"}
+	}
+	if jwk.IsPrivate() {
+		return nil, &DPoPError{"jwk header must not contain a private key"}
+	}
+	return jwk, nil
+}
+
+// sameHTU compares two HTTP URIs without query and fragment. Scheme and host are compared
+// case-insensitively, default ports and an empty path are normalized and percent-encodings
+// are compared in their canonical form (RFC 3986 section 6.2.2).
+func sameHTU(a, b string) bool {
+	normalizedA, err := normalizeHTU(a)
+	if err != nil {
+		return false
+	}
+	normalizedB, err := normalizeHTU(b)
+	if err != nil {
+		return false
+	}
+	return normalizedA == normalizedB
+}
+
+func normalizeHTU(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if (scheme != "http" && scheme != "https") || u.Host == "" {
+		return "", errors.New("Invalid HTTP URI: " + raw)
+	}
+	host := strings.ToLower(u.Hostname())
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port := u.Port(); port != "" && !(scheme == "http" && port == "80") && !(scheme == "https" && port == "443") {
+		host += ":" + port
+	}
+	path := (&url.URL{Path: u.Path}).EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	return scheme + "://" + host + path, nil
+}
+
+// accessTokenHash computes the "ath" claim, the base64url encoded SHA-256 hash of the access token.
+func accessTokenHash(accessToken string) string {
+	return base64.RawURLEncoding.EncodeToString(digest(crypto.SHA256, []byte(accessToken)))
+}
+
+// ValidateDPoPBinding returns a validator which checks that an access token is bound to the
+// key of a DPoP proof with the "jkt" member of its "cnf" claim (RFC 9449 section 6.1).
+func ValidateDPoPBinding(thumbprint string) Validator {
+	return func(token *JwtToken) error {
+		cnf, err := token.Claims.Confirmation()
+		if err != nil {
+			return err
+		}
+		if cnf == nil {
+			return &MissingClaimError{"cnf"}
+		}
+		if thumbprint == "" || subtle.ConstantTimeCompare([]byte(cnf.JKT), []byte(thumbprint)) != 1 {
+			return ErrDPoPBinding
+		}
+		return nil
+	}
+}
+
+// ReplayCache records the identifiers of proofs which have been used.
+type ReplayCache interface {
+	// Seen records the identifier until it expires and reports whether it has been
+	// recorded before.
+	Seen(id string, expires time.Time) bool
+}
+
+// MemoryReplayCache is an in-memory ReplayCache. Expired identifiers are removed when new ones
+// are recorded. A MemoryReplayCache may be used from multiple goroutines.
+type MemoryReplayCache struct {
+	mutex   sync.Mutex
+	entries map[string]time.Time
+	purged  time.Time
+	now     func() time.Time
+}
+
+// NewMemoryReplayCache creates an empty in-memory replay cache.
+func NewMemoryReplayCache() *MemoryReplayCache {
+	return &MemoryReplayCache{entries: map[string]time.Time{}, now: time.Now}
+}
+
+func (c *MemoryReplayCache) Seen(id string, expires time.Time) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := c.now()
+	if now.Sub(c.purged) > time.Minute {
+		for key, expiry := range c.entries {
+			if now.After(expiry) {
+				delete(c.entries, key)
+			}
+		}
+		c.purged = now
+	}
+	if expiry, ok := c.entries[id]; ok && !now.After(expiry) {
+		return true
+	}
+	c.entries[id] = expires
+	return false
+}
+
+// DPoPNonces provides server nonces for DPoP proofs (RFC 9449 section 8). The nonce is
+// replaced after every interval; the previous nonce stays valid for one more interval, so
+// clients are not rejected right after a rotation. A DPoPNonces may be used from multiple
+// goroutines.
+type DPoPNonces struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	current  string
+	previous string
+	rotated  time.Time
+	now      func() time.Time
+}
+
+// NewDPoPNonces creates a nonce source which rotates the nonce after the interval. The
+// interval must be positive.
+func NewDPoPNonces(interval time.Duration) (*DPoPNonces, error) {
+	if interval <= 0 {
+		return nil, errors.New("Nonce interval must be positive")
+	}
+	return &DPoPNonces{interval: interval, now: time.Now}, nil
+}
+
+// Nonce returns the current nonce, to be sent in the DPoP-Nonce header.
+func (n *DPoPNonces) Nonce() (string, error) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if err := n.rotate(); err != nil {
+		return "", err
+	}
+	return n.current, nil
+}
+
+// Valid reports whether the nonce is the current or the previous nonce. An error is
+// returned if a new nonce could not be generated.
+func (n *DPoPNonces) Valid(nonce string) (bool, error) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if err := n.rotate(); err != nil {
+		return false, err
+	}
+	if nonce == "" {
+		return false, nil
+	}
+	return subtle.ConstantTimeCompare([]byte(nonce), []byte(n.current)) == 1 ||
+		subtle.ConstantTimeCompare([]byte(nonce), []byte(n.previous)) == 1, nil
+}
+
+func (n *DPoPNonces) rotate() error {
+	now := n.now()
+	if n.current != "" && now.Sub(n.rotated) < n.interval {
+		return nil
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	if now.Sub(n.rotated) < 2*n.interval {
+		n.previous = n.current
+	} else {
+		n.previous = ""
+	}
+	n.current = base64.RawURLEncoding.EncodeToString(nonce)
+	n.rotated = now
+	return nil
+}
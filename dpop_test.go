@@ -0,0 +1,210 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func dpopSigner(t *testing.T) *DPoPSigner {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	jwk, _ := NewJWK(key)
+	signer, err := NewDPoPSigner(jwk, JWT_ES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func TestDPoP(t *testing.T) {
+	signer := dpopSigner(t)
+	proof, err := signer.Proof("GET", "https://RS.example.com:443/resource?id=1#top", "token", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unverified, _ := Decode(proof)
+	if unverified.Header.Typ != TYP_DPOP_JWT || unverified.Claims.Raw["htu"] != "https://RS.example.com:443/resource" {
+		t.Log(unverified.Header, unverified.Claims.Raw)
+		t.Fail()
+	}
+	verifier := NewDPoPVerifier()
+	verified, err := verifier.Verify(proof, "GET", "https://rs.example.com/%72esource?x", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verified.Thumbprint != signer.Thumbprint() {
+		t.Log(verified.Thumbprint)
+		t.Fail()
+	}
+	_, err = verifier.Verify(proof, "GET", "https://rs.example.com/resource", "token")
+	if err != ErrDPoPReplay {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestDPoPInvalidProofs(t *testing.T) {
+	signer := dpopSigner(t)
+	verifier := NewDPoPVerifier()
+	proof, _ := signer.Proof("POST", "https://rs.example.com/resource", "token", "")
+	for _, request := range [][3]string{
+		{"GET", "https://rs.example.com/resource", "token"},
+		{"POST", "https://rs.example.com/other", "token"},
+		{"POST", "http://rs.example.com/resource", "token"},
+		{"POST", "https://rs.example.com:8443/resource", "token"},
+		{"POST", "https://rs.example.com/resource", "other"},
+	} {
+		_, err := verifier.Verify(proof, request[0], request[1], request[2])
+		if !errors.Is(err, ErrInvalidDPoPProof) {
+			t.Log(request, err)
+			t.Fail()
+		}
+	}
+	// the failed attempts don't consume the proof
+	if _, err := verifier.Verify(proof, "POST", "https://rs.example.com/resource", "token"); err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+
+	hs256, _ := NewHS256(testSecret("secret"))
+	claims := &Claims{IssuedAt: time.Now().Unix(), Raw: map[string]interface{}{"jti": "1", "htm": "GET", "htu": "https://rs.example.com/"}}
+	_, err := verifier.Verify(forge(`{"alg":"HS256","typ":"dpop+jwt","jwk":{"kty":"oct","k":"c2VjcmV0"}}`, claims, hs256), "GET", "https://rs.example.com/", "")
+	if !errors.Is(err, ErrInvalidDPoPProof) {
+		t.Log(err)
+		t.Fail()
+	}
+	token, _ := Create(claims, signer.algorithm, WithHeader("jwk", signer.jwk))
+	_, err = verifier.Verify(token, "GET", "https://rs.example.com/", "")
+	if !errors.Is(err, ErrInvalidDPoPProof) {
+		t.Log(err)
+		t.Fail()
+	}
+	other := dpopSigner(t)
+	token, _ = Create(claims, other.algorithm, WithType(TYP_DPOP_JWT), WithHeader("jwk", signer.jwk))
+	_, err = verifier.Verify(token, "GET", "https://rs.example.com/", "")
+	if err != ErrInvalidSignature {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestDPoPAge(t *testing.T) {
+	signer := dpopSigner(t)
+	proof, _ := signer.Proof("GET", "https://rs.example.com/", "", "")
+	verifier := &DPoPVerifier{now: func() time.Time { return time.Now().Add(10 * time.Minute) }}
+	_, err := verifier.Verify(proof, "GET", "https://rs.example.com/", "")
+	if !errors.Is(err, ErrInvalidDPoPProof) {
+		t.Log(err)
+		t.Fail()
+	}
+	verifier.now = func() time.Time { return time.Now().Add(-time.Minute) }
+	_, err = verifier.Verify(proof, "GET", "https://rs.example.com/", "")
+	if !errors.Is(err, ErrInvalidDPoPProof) {
+		t.Log(err)
+		t.Fail()
+	}
+	verifier.Leeway = 2 * time.Minute
+	_, err = verifier.Verify(proof, "GET", "https://rs.example.com/", "")
+	if err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestDPoPNonce(t *testing.T) {
+	signer := dpopSigner(t)
+	now := time.Now()
+	nonces, err := NewDPoPNonces(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonces.now = func() time.Time { return now }
+	verifier := &DPoPVerifier{Nonces: nonces}
+	proof, _ := signer.Proof("GET", "https://rs.example.com/", "", "")
+	_, err = verifier.Verify(proof, "GET", "https://rs.example.com/", "")
+	if err != ErrUseDPoPNonce {
+		t.Log(err)
+		t.Fail()
+	}
+	nonce, _ := nonces.Nonce()
+	proof, _ = signer.Proof("GET", "https://rs.example.com/", "", nonce)
+	now = now.Add(90 * time.Second)
+	if current, _ := nonces.Nonce(); current == nonce {
+		t.Fail()
+	}
+	_, err = verifier.Verify(proof, "GET", "https://rs.example.com/", "")
+	if err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+	now = now.Add(time.Minute)
+	if valid, err := nonces.Valid(nonce); valid || err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+	for _, interval := range []time.Duration{0, -time.Minute} {
+		if _, err := NewDPoPNonces(interval); err == nil {
+			t.Log(interval)
+			t.Fail()
+		}
+	}
+}
+
+func TestDPoPRequestAndBinding(t *testing.T) {
+	signer := dpopSigner(t)
+	rs256, _ := NewRS256(mustReadFixture("rsa"))
+	accessToken := testAccessToken()
+	accessToken.Raw = map[string]interface{}{"cnf": map[string]interface{}{"jkt": signer.Thumbprint()}}
+	raw, _ := CreateAccessToken(accessToken, rs256)
+
+	proof, _ := signer.Proof("GET", "http://rs.example.com/resource", raw, "")
+	r := httptest.NewRequest("GET", "http://rs.example.com/resource?page=2", nil)
+	r.Header.Set("Authorization", "DPoP "+raw)
+	r.Header.Set(DPOP_HEADER, proof)
+	verified, err := NewDPoPVerifier().VerifyRequest(r, strings.TrimPrefix(r.Header.Get("Authorization"), "DPoP "))
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := NewAccessTokenVerifier(atIssuer, atResource, rs256)
+	if _, err := verifier.Verify(raw, ValidateDPoPBinding(verified.Thumbprint)); err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+	if _, err := verifier.Verify(raw, ValidateDPoPBinding(dpopSigner(t).Thumbprint())); err != ErrDPoPBinding {
+		t.Log(err)
+		t.Fail()
+	}
+	r.Header.Add(DPOP_HEADER, proof)
+	if _, err := NewDPoPVerifier().VerifyRequest(r, raw); !errors.Is(err, ErrInvalidDPoPProof) {
+		t.Log(err)
+		t.Fail()
+	}
+}
@@ -30,6 +30,7 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
+	"math/big"
 )
 
 const (
@@ -66,28 +67,62 @@ func newECDSA(name string, key []byte, hash crypto.Hash) (*_ecdsa, error) {
 	if err != nil {
 		return nil, err
 	}
-	params := privateKey.Params()
+	return newECDSAFromKey(name, privateKey, hash)
+}
+
+func newECDSAFromKey(name string, privateKey *ecdsa.PrivateKey, hash crypto.Hash) (*_ecdsa, error) {
+	if privateKey == nil || privateKey.D == nil {
+		return nil, errors.New("Invalid ECDSA private key")
+	}
+	e, err := newECDSAFromPublicKey(name, &privateKey.PublicKey, hash)
+	if err != nil {
+		return nil, err
+	}
+	e.privateKey = privateKey
+	return e, nil
+}
+
+func newECDSAFromPublicKey(name string, publicKey *ecdsa.PublicKey, hash crypto.Hash) (*_ecdsa, error) {
+	if publicKey == nil || publicKey.Curve == nil || publicKey.X == nil || publicKey.Y == nil ||
+		!publicKey.Curve.IsOnCurve(publicKey.X, publicKey.Y) {
+		return nil, errors.New("Invalid ECDSA public key")
+	}
+	params := publicKey.Params()
 	requiredKey := JWT_ECDS_MAP[name]
 	if requiredKey != params.Name {
 		return nil, errors.New("JWT algorithm does not match private key. Want: " + requiredKey + ". Have: " + params.Name)
 	}
-	return &_ecdsa{privateKey, &privateKey.PublicKey, hash, name}, nil
+	return &_ecdsa{nil, publicKey, hash, name}, nil
 }
 
+// sign returns the signature as the concatenation of R and S (RFC 7518 section 3.4).
 func (e *_ecdsa) sign(data []byte) ([]byte, error) {
-	hash := e.hash.New()
-	hash.Write(data)
-	sum := hash.Sum(nil)
-	return ecdsa.SignASN1(rand.Reader, e.privateKey, sum)
+	if e.privateKey == nil {
+		return nil, ErrVerifyOnly
+	}
+	sum := digest(e.hash, data)
+	r, s, err := ecdsa.Sign(rand.Reader, e.privateKey, sum)
+	if err != nil {
+		return nil, err
+	}
+	size := curveSize(e.publicKey)
+	signature := make([]byte, 2*size)
+	r.FillBytes(signature[:size])
+	s.FillBytes(signature[size:])
+	return signature, nil
 }
 
-// Verify Verifies signed data
+// Verify Verifies signed data. Only the concatenation of R and S is accepted, ASN.1 encoded
+// signatures are rejected so that a signature has exactly one valid encoding.
 func (e *_ecdsa) verify(data []byte, signature []byte) error {
-	hash := e.hash.New()
-	hash.Write(data)
-	sum := hash.Sum(nil)
-	verified := ecdsa.VerifyASN1(e.publicKey, sum, signature)
-	if verified != true {
+	size := curveSize(e.publicKey)
+	if len(signature) != 2*size {
+		return errors.New("Token could not be verified")
+	}
+	sum := digest(e.hash, data)
+	r := new(big.Int).SetBytes(signature[:size])
+	s := new(big.Int).SetBytes(signature[size:])
+	if ecdsa.Verify(e.publicKey, sum, r, s) != true {
 		return errors.New("Token could not be verified")
 	}
 	return nil
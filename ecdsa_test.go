@@ -25,6 +25,8 @@ package jwt
 
 import (
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
 	"testing"
 )
 
@@ -106,3 +108,20 @@ func TestECDSAInvalidAlgorithm(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestECDSASignatureFormat(t *testing.T) {
+	for name, size := range map[string]int{JWT_ES256: 64, JWT_ES348: 96, JWT_ES512: 132} {
+		key, _ := GenerateKey(name)
+		signature, err := key.Algorithm.Sign([]byte("data"))
+		if err != nil || len(signature) != size {
+			t.Log(name, len(signature), err)
+			t.Fail()
+		}
+		privateKey, _ := key.PrivateJWK.Key()
+		legacy, _ := ecdsa.SignASN1(rand.Reader, privateKey.(*ecdsa.PrivateKey), digest(signingHashes[name], []byte("data")))
+		if err := key.Algorithm.Verify([]byte("data"), legacy); err == nil {
+			t.Log(name, "ASN.1 encoded signature was accepted")
+			t.Fail()
+		}
+	}
+}
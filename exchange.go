@@ -0,0 +1,219 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+)
+
+const (
+	// TOKEN_EXCHANGE_GRANT_TYPE is the "grant_type" of token exchange requests (RFC 8693 section 2.1).
+	TOKEN_EXCHANGE_GRANT_TYPE = "urn:ietf:params:oauth:grant-type:token-exchange"
+	// TOKEN_TYPE_JWT identifies JWTs as subject and actor tokens (RFC 8693 section 3).
+	TOKEN_TYPE_JWT = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+var (
+	ErrActorNotAllowed   = errors.New("Actor is not allowed to act for the subject")
+	ErrActorChainTooDeep = errors.New("Delegation chain is too long")
+)
+
+// Actor identifies a party in the "act" and "may_act" claims (RFC 8693 section 4.1 and 4.4).
+// In the "act" claim, Actor is the previous actor of the delegation chain.
+type Actor struct {
+	Subject  string `json:"sub,omitempty"`
+	Issuer   string `json:"iss,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	Actor    *Actor `json:"act,omitempty"`
+}
+
+// Chain returns the actors of the delegation chain, starting with the current actor.
+func (a *Actor) Chain() []*Actor {
+	chain := []*Actor{}
+	for actor := a; actor != nil; actor = actor.Actor {
+		chain = append(chain, actor)
+	}
+	return chain
+}
+
+// matches reports whether the actor identifies the same party as the token. Members of the
+// actor which are empty are not compared.
+func (a *Actor) matches(token *JwtToken) bool {
+	if a.Subject == "" && a.ClientID == "" {
+		return false
+	}
+	if a.Subject != "" && a.Subject != token.Claims.Subject {
+		return false
+	}
+	if a.Issuer != "" && a.Issuer != token.Claims.Issuer {
+		return false
+	}
+	clientID, _ := token.Claims.Raw["client_id"].(string)
+	return a.ClientID == "" || a.ClientID == clientID
+}
+
+// Actor returns the "act" claim. It returns nil if the claim is not present.
+func (c *Claims) Actor() (*Actor, error) {
+	return actorMember(c.Raw, "act")
+}
+
+// SetActor sets the "act" claim.
+func (c *Claims) SetActor(actor *Actor) {
+	if c.Raw == nil {
+		c.Raw = map[string]interface{}{}
+	}
+	c.Raw["act"] = actor
+}
+
+// MayAct returns the "may_act" claim, the party which is allowed to act for the subject. It
+// returns nil if the claim is not present.
+func (c *Claims) MayAct() (*Actor, error) {
+	return actorMember(c.Raw, "may_act")
+}
+
+// SetMayAct sets the "may_act" claim.
+func (c *Claims) SetMayAct(actor *Actor) {
+	if c.Raw == nil {
+		c.Raw = map[string]interface{}{}
+	}
+	c.Raw["may_act"] = actor
+}
+
+func actorMember(raw map[string]interface{}, name string) (*Actor, error) {
+	value, ok := raw[name]
+	if !ok {
+		return nil, nil
+	}
+	if actor, ok := value.(*Actor); ok {
+		return actor, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	actor := &Actor{}
+	if err := json.Unmarshal(data, actor); err != nil {
+		return nil, errors.New("Invalid value of " + name + ": not an object")
+	}
+	return actor, nil
+}
+
+// TokenExchangeParams returns the form parameters of a token exchange request with JWTs as
+// subject and actor token. The actor token is omitted if empty.
+func TokenExchangeParams(subjectToken, actorToken string) url.Values {
+	params := url.Values{
+		"grant_type":         {TOKEN_EXCHANGE_GRANT_TYPE},
+		"subject_token":      {subjectToken},
+		"subject_token_type": {TOKEN_TYPE_JWT},
+	}
+	if actorToken != "" {
+		params.Set("actor_token", actorToken)
+		params.Set("actor_token_type", TOKEN_TYPE_JWT)
+	}
+	return params
+}
+
+// ExchangeClaims returns the claims of a token issued in a token exchange for the subject
+// token. If actorToken is not nil, its subject becomes the current actor of the "act" claim
+// and the delegation chain of the subject token is nested below it. If the subject token has
+// a "may_act" claim, the actor token must match it. Issuer, audience and expiry are set by
+// the caller before the token is created with Create or CreateAccessToken.
+func ExchangeClaims(subjectToken, actorToken *JwtToken) (*Claims, error) {
+	if subjectToken == nil {
+		return nil, errors.New("Subject token can't be nil")
+	}
+	if subjectToken.Claims.Subject == "" {
+		return nil, &MissingClaimError{"sub"}
+	}
+	claims := &Claims{Subject: subjectToken.Claims.Subject, Raw: map[string]interface{}{}}
+	if actorToken == nil {
+		return claims, nil
+	}
+	if actorToken.Claims.Subject == "" {
+		return nil, &MissingClaimError{"sub"}
+	}
+	mayAct, err := subjectToken.Claims.MayAct()
+	if err != nil {
+		return nil, err
+	}
+	if mayAct != nil && !mayAct.matches(actorToken) {
+		return nil, ErrActorNotAllowed
+	}
+	previous, err := subjectToken.Claims.Actor()
+	if err != nil {
+		return nil, err
+	}
+	claims.SetActor(&Actor{
+		Subject: actorToken.Claims.Subject,
+		Issuer:  actorToken.Claims.Issuer,
+		Actor:   previous,
+	})
+	return claims, nil
+}
+
+// ValidateActorChainDepth returns a validator which limits the number of actors in the
+// delegation chain of the "act" claim. Tokens without "act" claim are accepted.
+func ValidateActorChainDepth(maxDepth int) Validator {
+	return func(token *JwtToken) error {
+		actor, err := token.Claims.Actor()
+		if err != nil {
+			return err
+		}
+		if len(actor.Chain()) > maxDepth {
+			return ErrActorChainTooDeep
+		}
+		return nil
+	}
+}
+
+// ValidateActors returns a validator which checks that every actor of the delegation chain of
+// the "act" claim has the subject and issuer of one of the allowed actors. Subjects are only
+// unique per issuer, so an allowed actor without issuer only matches actors without issuer.
+// Tokens without "act" claim are accepted.
+func ValidateActors(allowed ...*Actor) Validator {
+	return func(token *JwtToken) error {
+		actor, err := token.Claims.Actor()
+		if err != nil {
+			return err
+		}
+		for _, a := range actor.Chain() {
+			if !a.allowed(allowed) {
+				return ErrActorNotAllowed
+			}
+		}
+		return nil
+	}
+}
+
+// allowed reports whether one of the allowed actors has the subject and issuer of the actor.
+func (a *Actor) allowed(allowed []*Actor) bool {
+	for _, other := range allowed {
+		if other.Subject != "" && other.Subject == a.Subject && other.Issuer == a.Issuer {
+			return true
+		}
+	}
+	return false
+}
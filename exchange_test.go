@@ -0,0 +1,125 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"testing"
+	"time"
+)
+
+func exchangeToken(t *testing.T, alg Algorithm, claims *Claims) *JwtToken {
+	claims.Expires = time.Now().Add(time.Hour).Unix()
+	raw, err := Create(claims, alg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := Parse(raw, alg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestTokenExchange(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	subject := &Claims{Subject: "user", Issuer: atIssuer}
+	subject.SetMayAct(&Actor{Subject: "service-a"})
+	subjectToken := exchangeToken(t, hs256, subject)
+	actorToken := exchangeToken(t, hs256, &Claims{Subject: "service-a", Issuer: atIssuer})
+
+	claims, err := ExchangeClaims(subjectToken, actorToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := exchangeToken(t, hs256, claims)
+	actor, err := first.Claims.Actor()
+	if err != nil || first.Claims.Subject != "user" || actor.Subject != "service-a" || actor.Issuer != atIssuer || actor.Actor != nil {
+		t.Log(first.Claims.Raw, err)
+		t.Fail()
+	}
+
+	// service-a exchanges the token for a token used by service-b
+	nextActor := exchangeToken(t, hs256, &Claims{Subject: "service-b"})
+	claims, _ = ExchangeClaims(first, nextActor)
+	second := exchangeToken(t, hs256, claims)
+	actor, _ = second.Claims.Actor()
+	chain := actor.Chain()
+	if len(chain) != 2 || chain[0].Subject != "service-b" || chain[1].Subject != "service-a" {
+		t.Log(second.Claims.Raw)
+		t.Fail()
+	}
+	if err := second.Validate(ValidateActorChainDepth(2), ValidateActors(&Actor{Subject: "service-a", Issuer: atIssuer}, &Actor{Subject: "service-b"})); err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+	if err := second.Validate(ValidateActorChainDepth(1)); err != ErrActorChainTooDeep {
+		t.Log(err)
+		t.Fail()
+	}
+	for _, allowed := range [][]*Actor{
+		{{Subject: "service-b"}},
+		{{Subject: "service-a"}, {Subject: "service-b"}},
+		{{Subject: "service-a", Issuer: "https://other.example.com"}, {Subject: "service-b"}},
+	} {
+		if err := second.Validate(ValidateActors(allowed...)); err != ErrActorNotAllowed {
+			t.Log(err)
+			t.Fail()
+		}
+	}
+	if err := subjectToken.Validate(ValidateActorChainDepth(0), ValidateActors()); err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestTokenExchangeMayAct(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	subject := &Claims{Subject: "user"}
+	subject.SetMayAct(&Actor{Subject: "service-a", Issuer: atIssuer})
+	subjectToken := exchangeToken(t, hs256, subject)
+	for _, claims := range []*Claims{
+		{Subject: "service-b", Issuer: atIssuer},
+		{Subject: "service-a", Issuer: "https://other.example.com"},
+	} {
+		if _, err := ExchangeClaims(subjectToken, exchangeToken(t, hs256, claims)); err != ErrActorNotAllowed {
+			t.Log(claims, err)
+			t.Fail()
+		}
+	}
+	// without actor token the subject is impersonated
+	claims, err := ExchangeClaims(subjectToken, nil)
+	if err != nil || claims.Subject != "user" || claims.Raw["act"] != nil {
+		t.Log(claims, err)
+		t.Fail()
+	}
+	params := TokenExchangeParams("subject", "")
+	if params.Get("grant_type") != TOKEN_EXCHANGE_GRANT_TYPE || params.Get("subject_token_type") != TOKEN_TYPE_JWT || params.Has("actor_token") {
+		t.Log(params)
+		t.Fail()
+	}
+	invalid := exchangeToken(t, hs256, &Claims{Subject: "user", Raw: map[string]interface{}{"act": "service-a"}})
+	if _, err := invalid.Claims.Actor(); err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,109 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package grpcauth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/tezli/jwt"
+)
+
+// DefaultRefreshBefore is how long before expiry a cached token is replaced.
+const DefaultRefreshBefore = 30 * time.Second
+
+// Credentials are per-RPC credentials which mint tokens with jwt.Create. Tokens are cached
+// until shortly before they expire. Credentials may be used from multiple goroutines.
+type Credentials struct {
+	algorithm jwt.Algorithm
+	claims    jwt.Claims
+	lifetime  time.Duration
+	// RefreshBefore is how long before expiry a new token is minted. It must be shorter
+	// than the lifetime.
+	RefreshBefore time.Duration
+	// Insecure allows sending tokens over connections without transport security.
+	Insecure bool
+
+	mutex   sync.Mutex
+	token   string
+	expires time.Time
+	now     func() time.Time
+}
+
+// NewCredentials creates new per-RPC credentials. Every minted token carries a copy of the
+// claims and expires after lifetime, which must be longer than DefaultRefreshBefore.
+func NewCredentials(algorithm jwt.Algorithm, claims jwt.Claims, lifetime time.Duration) (*Credentials, error) {
+	if algorithm == nil {
+		return nil, errors.New("Algorithm can't be nil")
+	}
+	if lifetime <= DefaultRefreshBefore {
+		return nil, errors.New("Lifetime must be longer than DefaultRefreshBefore")
+	}
+	return &Credentials{
+		algorithm:     algorithm,
+		claims:        claims,
+		lifetime:      lifetime,
+		RefreshBefore: DefaultRefreshBefore,
+		now:           time.Now,
+	}, nil
+}
+
+// Token returns the cached token or mints a new one if the cached token is about to expire.
+func (c *Credentials) Token() (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.lifetime <= c.RefreshBefore {
+		return "", errors.New("Lifetime must be longer than RefreshBefore")
+	}
+	now := c.now()
+	if c.token != "" && now.Add(c.RefreshBefore).Before(c.expires) {
+		return c.token, nil
+	}
+	expires := now.Add(c.lifetime)
+	claims := c.claims
+	claims.Expires = expires.Unix()
+	token, err := jwt.Create(&claims, c.algorithm)
+	if err != nil {
+		return "", err
+	}
+	c.token = token
+	c.expires = expires
+	return token, nil
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials.
+func (c *Credentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token, err := c.Token()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"authorization": "Bearer " + token}, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials.
+func (c *Credentials) RequireTransportSecurity() bool {
+	return !c.Insecure
+}
@@ -0,0 +1,21 @@
+module github.com/tezli/jwt/grpcauth
+
+go 1.21
+
+require (
+	github.com/tezli/jwt v0.0.0-20261019183550-5f153d1e3cbc
+	google.golang.org/grpc v1.67.1
+)
+
+require (
+	golang.org/x/net v0.28.0 // indirect
+	golang.org/x/sys v0.24.0 // indirect
+	golang.org/x/text v0.17.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240814211410-ddb44dafa142 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+)
+
+// The required version is the jwt commit which added the APIs used by grpcauth. Raise it to
+// the release tag once jwt is tagged. Builds within the repository use the jwt package of
+// the same commit; modules depending on grpcauth ignore the replacement.
+replace github.com/tezli/jwt => ../
@@ -0,0 +1,128 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+// Package grpcauth provides gRPC interceptors and per-RPC credentials for JWT authentication.
+package grpcauth
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/tezli/jwt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Authenticator authenticates incoming RPCs with the bearer token in the "authorization" metadata.
+type Authenticator struct {
+	// Algorithm verifies the token signature.
+	Algorithm jwt.Algorithm
+	// KeySet verifies the token signature with the key matching the "kid" header. It takes
+	// precedence over Algorithm.
+	KeySet *jwt.KeySet
+	// Validators are run after the signature and the time based claims have been checked.
+	Validators []jwt.Validator
+}
+
+// NewAuthenticator creates a new authenticator which verifies tokens with the algorithm.
+func NewAuthenticator(algorithm jwt.Algorithm, validators ...jwt.Validator) *Authenticator {
+	return &Authenticator{Algorithm: algorithm, Validators: validators}
+}
+
+// NewKeySetAuthenticator creates a new authenticator which verifies tokens with the key set.
+func NewKeySetAuthenticator(keys *jwt.KeySet, validators ...jwt.Validator) *Authenticator {
+	return &Authenticator{KeySet: keys, Validators: validators}
+}
+
+// Authenticate verifies the token in the incoming metadata of ctx and returns a context
+// carrying the parsed token. Errors are gRPC status errors.
+func (a *Authenticator) Authenticate(ctx context.Context) (context.Context, error) {
+	raw, err := tokenFromMetadata(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	token, err := jwt.ParseAndValidate(raw, a.Algorithm, a.KeySet, a.Validators...)
+	if err != nil {
+		var scopeErr *jwt.InsufficientScopeError
+		if errors.As(err, &scopeErr) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return jwt.NewContext(ctx, token), nil
+}
+
+// UnaryServerInterceptor returns a server interceptor which authenticates unary RPCs.
+func (a *Authenticator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.Authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a server interceptor which authenticates streaming RPCs.
+func (a *Authenticator) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.Authenticate(stream.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{stream, ctx})
+	}
+}
+
+// serverStream overrides the context of a server stream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func tokenFromMetadata(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", jwt.ErrTokenNotFound
+	}
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", jwt.ErrTokenNotFound
+	}
+	if len(values) > 1 {
+		return "", errors.New("Multiple authorization values are not supported")
+	}
+	value := values[0]
+	if len(value) < 7 || !strings.EqualFold(value[:7], "Bearer ") {
+		return "", errors.New("Authorization is not a bearer token")
+	}
+	return strings.TrimSpace(value[7:]), nil
+}
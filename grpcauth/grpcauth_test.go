@@ -0,0 +1,217 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package grpcauth
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/tezli/jwt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type healthServer struct {
+	*health.Server
+	tokens chan *jwt.JwtToken
+}
+
+// secret is long enough for HS256 and HS512.
+var secret = []byte("grpcauth test secret, long enough for every HMAC algorithm: HS512")
+
+func (s *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	token, _ := jwt.FromContext(ctx)
+	s.tokens <- token
+	return s.Server.Check(ctx, req)
+}
+
+func (s *healthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	token, _ := jwt.FromContext(stream.Context())
+	s.tokens <- token
+	return stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING})
+}
+
+func startServer(t *testing.T, auth *Authenticator) (*healthServer, func(...grpc.DialOption) healthpb.HealthClient) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(auth.UnaryServerInterceptor()),
+		grpc.StreamInterceptor(auth.StreamServerInterceptor()),
+	)
+	hs := &healthServer{health.NewServer(), make(chan *jwt.JwtToken, 1)}
+	healthpb.RegisterHealthServer(server, hs)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	return hs, func(opts ...grpc.DialOption) healthpb.HealthClient {
+		dialer := func(context.Context, string) (net.Conn, error) { return listener.Dial() }
+		opts = append(opts,
+			grpc.WithContextDialer(dialer),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		conn, err := grpc.NewClient("passthrough:///bufnet", opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return healthpb.NewHealthClient(conn)
+	}
+}
+
+func newCredentials(t *testing.T, alg jwt.Algorithm) *Credentials {
+	creds, err := NewCredentials(alg, jwt.Claims{Subject: "client"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds.Insecure = true
+	return creds
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	alg, _ := jwt.NewHS256(secret)
+	hs, client := startServer(t, NewAuthenticator(alg))
+	c := client(grpc.WithPerRPCCredentials(newCredentials(t, alg)))
+	_, err := c.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	token := <-hs.tokens
+	if token == nil || token.Claims.Subject != "client" {
+		t.Fail()
+	}
+}
+
+func TestStreamInterceptor(t *testing.T) {
+	alg, _ := jwt.NewHS256(secret)
+	key, _ := jwt.NewKey("key1", alg)
+	hs, client := startServer(t, NewKeySetAuthenticator(jwt.NewKeySet(key)))
+	c := client(grpc.WithPerRPCCredentials(newCredentials(t, key)))
+	stream, err := c.Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	_, err = stream.Recv()
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	token := <-hs.tokens
+	if token == nil || token.Header.Kid != "key1" {
+		t.Fail()
+	}
+}
+
+func TestUnauthenticated(t *testing.T) {
+	alg, _ := jwt.NewHS256(secret)
+	_, client := startServer(t, NewAuthenticator(alg))
+	c := client()
+	_, err := c.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Log(err)
+		t.Fail()
+	}
+	stream, _ := c.Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	_, err = stream.Recv()
+	if status.Code(err) != codes.Unauthenticated {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestInvalidToken(t *testing.T) {
+	alg, _ := jwt.NewHS256(secret)
+	other, _ := jwt.NewHS512(secret)
+	_, client := startServer(t, NewAuthenticator(alg))
+	c := client(grpc.WithPerRPCCredentials(newCredentials(t, other)))
+	_, err := c.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestPermissionDenied(t *testing.T) {
+	alg, _ := jwt.NewHS256(secret)
+	_, client := startServer(t, NewAuthenticator(alg, jwt.Require(jwt.AllScopes("admin"))))
+	c := client(grpc.WithPerRPCCredentials(newCredentials(t, alg)))
+	_, err := c.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestCredentialsCache(t *testing.T) {
+	alg, _ := jwt.NewHS256(secret)
+	creds := newCredentials(t, alg)
+	now := time.Now()
+	creds.now = func() time.Time { return now }
+	token1, _ := creds.Token()
+	now = now.Add(30 * time.Minute)
+	token2, _ := creds.Token()
+	if token1 != token2 {
+		t.Fail()
+	}
+	now = now.Add(30*time.Minute - DefaultRefreshBefore)
+	token3, _ := creds.Token()
+	if token3 == token2 {
+		t.Fail()
+	}
+	md, err := creds.GetRequestMetadata(context.Background())
+	if err != nil || md["authorization"] != "Bearer "+token3 {
+		t.Fail()
+	}
+	if creds.RequireTransportSecurity() {
+		t.Fail()
+	}
+}
+
+func TestCredentialsInvalid(t *testing.T) {
+	_, err := NewCredentials(nil, jwt.Claims{}, time.Hour)
+	if err == nil {
+		t.Fail()
+	}
+	alg, _ := jwt.NewHS256(secret)
+	_, err = NewCredentials(alg, jwt.Claims{}, 0)
+	if err == nil {
+		t.Fail()
+	}
+	_, err = NewCredentials(alg, jwt.Claims{}, DefaultRefreshBefore)
+	if err == nil {
+		t.Fail()
+	}
+	creds, _ := NewCredentials(alg, jwt.Claims{}, time.Minute)
+	creds.RefreshBefore = time.Minute
+	if _, err := creds.Token(); err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,66 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"crypto"
+	"hash"
+	"sync"
+
+	// register the hash functions used by the algorithms
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+// hashPools holds reusable hash states for the hash functions used by the algorithms.
+// The map is not modified after initialization.
+var hashPools = map[crypto.Hash]*sync.Pool{
+	crypto.SHA256: newHashPool(crypto.SHA256.New),
+	crypto.SHA384: newHashPool(crypto.SHA384.New),
+	crypto.SHA512: newHashPool(crypto.SHA512.New),
+}
+
+func newHashPool(new func() hash.Hash) *sync.Pool {
+	return &sync.Pool{
+		New: func() interface{} {
+			return new()
+		},
+	}
+}
+
+// digest returns the hash of data using a pooled hash state.
+func digest(h crypto.Hash, data []byte) []byte {
+	pool, ok := hashPools[h]
+	if !ok {
+		hasher := h.New()
+		hasher.Write(data)
+		return hasher.Sum(nil)
+	}
+	hasher := pool.Get().(hash.Hash)
+	hasher.Reset()
+	hasher.Write(data)
+	sum := hasher.Sum(nil)
+	pool.Put(hasher)
+	return sum
+}
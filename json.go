@@ -0,0 +1,200 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"encoding/json"
+	"strconv"
+	"unicode/utf8"
+)
+
+// parseObject decodes a JSON object into the same values as json.Unmarshal into a
+// map[string]interface{}. Duplicate member names and nesting deeper than maxDepth are
+// rejected. The data is copied into a single string and decoded strings are substrings
+// of it, so only maps, slices and interface values are allocated.
+func parseObject(data []byte, maxDepth int) (map[string]interface{}, error) {
+	if !json.Valid(data) {
+		return nil, ErrInvalidJSON
+	}
+	p := &jsonParser{data: string(data), maxDepth: maxDepth}
+	p.skipSpace()
+	if p.data[p.pos] != '{' {
+		return nil, ErrInvalidJSON
+	}
+	return p.object(1)
+}
+
+// jsonParser decodes JSON which has already been checked with json.Valid.
+type jsonParser struct {
+	data     string
+	pos      int
+	maxDepth int
+}
+
+// parseArray decodes a JSON array with the same rules as parseObject. It is used for
+// disclosures, which are encoded as arrays.
+func parseArray(data []byte, maxDepth int) ([]interface{}, error) {
+	if !json.Valid(data) {
+		return nil, ErrInvalidJSON
+	}
+	p := &jsonParser{data: string(data), maxDepth: maxDepth}
+	p.skipSpace()
+	if p.data[p.pos] != '[' {
+		return nil, ErrInvalidJSON
+	}
+	return p.array(1)
+}
+
+func (p *jsonParser) skipSpace() {
+	for p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *jsonParser) value(depth int) (interface{}, error) {
+	p.skipSpace()
+	switch p.data[p.pos] {
+	case '{':
+		return p.object(depth + 1)
+	case '[':
+		return p.array(depth + 1)
+	case '"':
+		return p.string()
+	case 't':
+		p.pos += len("true")
+		return true, nil
+	case 'f':
+		p.pos += len("false")
+		return false, nil
+	case 'n':
+		p.pos += len("null")
+		return nil, nil
+	}
+	return p.number()
+}
+
+func (p *jsonParser) object(depth int) (map[string]interface{}, error) {
+	if p.maxDepth > 0 && depth > p.maxDepth {
+		return nil, ErrJSONTooDeep
+	}
+	object := map[string]interface{}{}
+	p.pos++
+	p.skipSpace()
+	if p.data[p.pos] == '}' {
+		p.pos++
+		return object, nil
+	}
+	for {
+		p.skipSpace()
+		name, err := p.string()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := object[name]; ok {
+			return nil, ErrDuplicateJSONName
+		}
+		p.skipSpace()
+		p.pos++ // ':'
+		value, err := p.value(depth)
+		if err != nil {
+			return nil, err
+		}
+		object[name] = value
+		p.skipSpace()
+		p.pos++
+		if p.data[p.pos-1] == '}' {
+			return object, nil
+		}
+	}
+}
+
+func (p *jsonParser) array(depth int) ([]interface{}, error) {
+	if p.maxDepth > 0 && depth > p.maxDepth {
+		return nil, ErrJSONTooDeep
+	}
+	array := []interface{}{}
+	p.pos++
+	p.skipSpace()
+	if p.data[p.pos] == ']' {
+		p.pos++
+		return array, nil
+	}
+	for {
+		value, err := p.value(depth)
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, value)
+		p.skipSpace()
+		p.pos++
+		if p.data[p.pos-1] == ']' {
+			return array, nil
+		}
+	}
+}
+
+// string returns plain strings as substrings of the data. Strings with escape sequences
+// or invalid UTF-8 are decoded by encoding/json.
+func (p *jsonParser) string() (string, error) {
+	start := p.pos
+	plain := true
+	for p.pos++; p.data[p.pos] != '"'; p.pos++ {
+		if p.data[p.pos] == '\\' {
+			plain = false
+			p.pos++
+		}
+	}
+	p.pos++
+	value := p.data[start+1 : p.pos-1]
+	if plain && utf8.ValidString(value) {
+		return value, nil
+	}
+	var unescaped string
+	if err := json.Unmarshal([]byte(p.data[start:p.pos]), &unescaped); err != nil {
+		return "", ErrInvalidJSON
+	}
+	return unescaped, nil
+}
+
+func (p *jsonParser) number() (float64, error) {
+	start := p.pos
+	for p.pos < len(p.data) {
+		switch c := p.data[p.pos]; {
+		case c >= '0' && c <= '9', c == '-', c == '+', c == '.', c == 'e', c == 'E':
+			p.pos++
+			continue
+		}
+		break
+	}
+	number, err := strconv.ParseFloat(p.data[start:p.pos], 64)
+	if err != nil {
+		return 0, ErrInvalidJSON
+	}
+	return number, nil
+}
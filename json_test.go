@@ -0,0 +1,100 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+var jsonObjects = []string{
+	`{}`,
+	` { "a" : 1 , "b" : [ ] , "c" : { } } `,
+	`{"s":"plain","e":"esc\"apedé\n","u":"ünïcödé","x":"😀"}`,
+	`{"n":[0,-1,1.5,1e3,-2.5E-3,9007199254740993],"b":[true,false,null]}`,
+	`{"nested":{"a":[{"b":{"c":[[]]}}]}}`,
+	"{\"invalid\":\"\xff\xfe\"}",
+}
+
+func TestParseObject(t *testing.T) {
+	for _, data := range jsonObjects {
+		var expected map[string]interface{}
+		if err := json.Unmarshal([]byte(data), &expected); err != nil {
+			t.Fatal(err)
+		}
+		actual, err := parseObject([]byte(data), 0)
+		if err != nil {
+			t.Log(data, err)
+			t.Fail()
+			continue
+		}
+		if !reflect.DeepEqual(expected, actual) {
+			t.Log(data, expected, actual)
+			t.Fail()
+		}
+	}
+}
+
+func TestParseObjectErrors(t *testing.T) {
+	for data, expected := range map[string]error{
+		`{"a":1,"a":2}`:         ErrDuplicateJSONName,
+		`{"\u0061":1,"a":2}`:    ErrDuplicateJSONName,
+		`{"a":{"b":1,"b":1}}`:   ErrDuplicateJSONName,
+		`{"a":[{"b":[{}]}]}`:    ErrJSONTooDeep,
+		`{"a":1e400}`:           ErrInvalidJSON,
+		`[]`:                    ErrInvalidJSON,
+		`{"a":1`:                ErrInvalidJSON,
+		`{"a":1} {}`:            ErrInvalidJSON,
+		`{"a":1,"b":{"a":1}}`:   nil,
+		`{"a":[{"b":[]}]}`:      nil,
+		`{"a":"\"","b":"\\\\"}`: nil,
+	} {
+		_, err := parseObject([]byte(data), 4)
+		if err != expected {
+			t.Log(data, err)
+			t.Fail()
+		}
+	}
+}
+
+func FuzzParseObject(f *testing.F) {
+	for _, data := range jsonObjects {
+		f.Add([]byte(data))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var expected map[string]interface{}
+		expectedErr := json.Unmarshal(data, &expected)
+		actual, err := parseObject(data, 0)
+		if err == ErrDuplicateJSONName {
+			return
+		}
+		if (err != nil) != (expectedErr != nil) {
+			t.Fatal(string(data), err, expectedErr)
+		}
+		if err == nil && !reflect.DeepEqual(expected, actual) {
+			t.Fatal(string(data), expected, actual)
+		}
+	})
+}
@@ -0,0 +1,183 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+)
+
+const (
+	JWE_A128GCM = "A128GCM"
+	JWE_A192GCM = "A192GCM"
+	JWE_A256GCM = "A256GCM"
+)
+
+var JWE_ENC_KEY_SIZE = map[string]int{
+	JWE_A128GCM: 16,
+	JWE_A192GCM: 24,
+	JWE_A256GCM: 32,
+}
+
+// JweHeader represents the protected header of a JWE
+type JweHeader struct {
+	Alg string `json:"alg"`
+	Enc string `json:"enc"`
+	Cty string `json:"cty,omitempty"`
+	P2s string `json:"p2s,omitempty"`
+	P2c int    `json:"p2c,omitempty"`
+}
+
+// jwe holds the decoded parts of a JWE in compact serialization.
+type jwe struct {
+	header        JweHeader
+	encodedHeader string
+	encryptedKey  []byte
+	iv            []byte
+	ciphertext    []byte
+	tag           []byte
+}
+
+func parseJWE(token string) (*jwe, error) {
+	splitted := strings.Split(token, ".")
+	if len(splitted) != 5 {
+		return nil, errors.New("Invalid JWE format")
+	}
+	raw, err := decodeJSON(splitted[0], DefaultLimits.MaxHeaderSize, DefaultLimits.MaxDepth)
+	if err != nil {
+		return nil, err
+	}
+	header, err := jweHeaderFromMap(raw)
+	if err != nil {
+		return nil, err
+	}
+	parts := make([][]byte, 5)
+	for i, part := range splitted[1:] {
+		decoded, err := base64URL.DecodeString(part)
+		if err != nil {
+			return nil, err
+		}
+		parts[i+1] = decoded
+	}
+	return &jwe{*header, splitted[0], parts[1], parts[2], parts[3], parts[4]}, nil
+}
+
+// jweHeaderFromMap converts a decoded JWE header. No header extensions are understood, so
+// headers with a "crit" parameter are rejected (RFC 7516 section 4.1.13).
+func jweHeaderFromMap(raw map[string]interface{}) (*JweHeader, error) {
+	if _, ok := raw["crit"]; ok {
+		return nil, ErrUnsupportedCriticalHeader
+	}
+	header := &JweHeader{}
+	var err error
+	if header.Alg, err = stringMember(raw, "alg"); err != nil {
+		return nil, err
+	}
+	if header.Enc, err = stringMember(raw, "enc"); err != nil {
+		return nil, err
+	}
+	if header.Cty, err = stringMember(raw, "cty"); err != nil {
+		return nil, err
+	}
+	if header.P2s, err = stringMember(raw, "p2s"); err != nil {
+		return nil, err
+	}
+	p2c, err := intMember(raw, "p2c")
+	if err != nil {
+		return nil, err
+	}
+	if p2c < 0 || p2c > math.MaxInt32 {
+		return nil, errors.New("Invalid value of p2c: out of range")
+	}
+	header.P2c = int(p2c)
+	return header, nil
+}
+
+func (e *jwe) serialize() string {
+	return e.encodedHeader + "." +
+		base64.RawURLEncoding.EncodeToString(e.encryptedKey) + "." +
+		base64.RawURLEncoding.EncodeToString(e.iv) + "." +
+		base64.RawURLEncoding.EncodeToString(e.ciphertext) + "." +
+		base64.RawURLEncoding.EncodeToString(e.tag)
+}
+
+// sealJWE encrypts plaintext with the content encryption key and returns the
+// JWE. The encoded protected header is used as additional authenticated data.
+func sealJWE(header *JweHeader, encryptedKey, cek, plaintext []byte) (string, error) {
+	gcm, err := newGCM(header.Enc, cek)
+	if err != nil {
+		return "", err
+	}
+	encoded, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	encodedHeader := base64.RawURLEncoding.EncodeToString(encoded)
+	iv := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nil, iv, plaintext, []byte(encodedHeader))
+	split := len(sealed) - gcm.Overhead()
+	e := &jwe{*header, encodedHeader, encryptedKey, iv, sealed[:split], sealed[split:]}
+	return e.serialize(), nil
+}
+
+// open decrypts the ciphertext of the JWE with the content encryption key.
+func (e *jwe) open(cek []byte) ([]byte, error) {
+	gcm, err := newGCM(e.header.Enc, cek)
+	if err != nil {
+		return nil, err
+	}
+	if len(e.iv) != gcm.NonceSize() || len(e.tag) != gcm.Overhead() {
+		return nil, errors.New("Invalid JWE initialization vector or authentication tag")
+	}
+	sealed := append(append([]byte{}, e.ciphertext...), e.tag...)
+	plaintext, err := gcm.Open(nil, e.iv, sealed, []byte(e.encodedHeader))
+	if err != nil {
+		return nil, errors.New("JWE could not be decrypted")
+	}
+	return plaintext, nil
+}
+
+func newGCM(enc string, cek []byte) (cipher.AEAD, error) {
+	size, ok := JWE_ENC_KEY_SIZE[enc]
+	if !ok {
+		return nil, errors.New("Unsupported JWE content encryption: " + enc)
+	}
+	if len(cek) != size {
+		return nil, errors.New("Invalid content encryption key size")
+	}
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
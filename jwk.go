@@ -0,0 +1,358 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"strconv"
+)
+
+// JWK represents a JSON Web Key (RFC 7517). Members which are not used by a key type are omitted.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	D   string `json:"d,omitempty"`
+	P   string `json:"p,omitempty"`
+	Q   string `json:"q,omitempty"`
+	Dp  string `json:"dp,omitempty"`
+	Dq  string `json:"dq,omitempty"`
+	Qi  string `json:"qi,omitempty"`
+	K   string `json:"k,omitempty"`
+}
+
+// NewJWK creates a JWK from a key. Supported keys are *rsa.PrivateKey, *rsa.PublicKey,
+// *ecdsa.PrivateKey, *ecdsa.PublicKey, ed25519.PrivateKey, ed25519.PublicKey and
+// []byte for HMAC secrets.
+func NewJWK(key interface{}) (*JWK, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if len(k.Primes) != 2 {
+			return nil, errors.New("RSA keys with more than two primes are not supported")
+		}
+		k.Precompute()
+		jwk, _ := NewJWK(&k.PublicKey)
+		jwk.D = encodeInt(k.D)
+		jwk.P = encodeInt(k.Primes[0])
+		jwk.Q = encodeInt(k.Primes[1])
+		jwk.Dp = encodeInt(k.Precomputed.Dp)
+		jwk.Dq = encodeInt(k.Precomputed.Dq)
+		jwk.Qi = encodeInt(k.Precomputed.Qinv)
+		return jwk, nil
+	case *rsa.PublicKey:
+		return &JWK{Kty: "RSA", N: encodeInt(k.N), E: encodeInt(big.NewInt(int64(k.E)))}, nil
+	case *ecdsa.PrivateKey:
+		jwk, err := NewJWK(&k.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		jwk.D = encodeFixed(k.D, curveSize(&k.PublicKey))
+		return jwk, nil
+	case *ecdsa.PublicKey:
+		name := k.Curve.Params().Name
+		if name != ECDSA_P256 && name != ECDSA_P348 && name != ECDSA_P521 {
+			return nil, errors.New("Unsupported curve: " + name)
+		}
+		size := curveSize(k)
+		return &JWK{Kty: "EC", Crv: name, X: encodeFixed(k.X, size), Y: encodeFixed(k.Y, size)}, nil
+	case ed25519.PrivateKey:
+		jwk, _ := NewJWK(k.Public())
+		jwk.D = base64.RawURLEncoding.EncodeToString(k.Seed())
+		return jwk, nil
+	case ed25519.PublicKey:
+		return &JWK{Kty: "OKP", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(k)}, nil
+	case []byte:
+		if len(k) == 0 {
+			return nil, errors.New("Secret can't be empty")
+		}
+		return &JWK{Kty: "oct", K: base64.RawURLEncoding.EncodeToString(k)}, nil
+	}
+	return nil, errors.New("Unsupported key type")
+}
+
+// Public returns a copy of the JWK without private members. It returns nil for symmetric keys.
+func (j *JWK) Public() *JWK {
+	if j.Kty == "oct" {
+		return nil
+	}
+	return &JWK{Kty: j.Kty, Kid: j.Kid, Use: j.Use, Alg: j.Alg, Crv: j.Crv, X: j.X, Y: j.Y, N: j.N, E: j.E}
+}
+
+// IsPrivate reports whether the JWK contains private or secret key material.
+func (j *JWK) IsPrivate() bool {
+	return j.D != "" || j.K != ""
+}
+
+// Key returns the key of the JWK: *rsa.PrivateKey, *rsa.PublicKey, *ecdsa.PrivateKey,
+// *ecdsa.PublicKey, ed25519.PrivateKey, ed25519.PublicKey or []byte for HMAC secrets.
+func (j *JWK) Key() (interface{}, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := decodeInt(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(j.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("Invalid RSA exponent")
+		}
+		publicKey := &rsa.PublicKey{N: n, E: int(e.Int64())}
+		if j.D == "" {
+			return publicKey, nil
+		}
+		privateKey := &rsa.PrivateKey{PublicKey: *publicKey}
+		if privateKey.D, err = decodeInt(j.D); err != nil {
+			return nil, err
+		}
+		p, err := decodeInt(j.P)
+		if err != nil {
+			return nil, err
+		}
+		q, err := decodeInt(j.Q)
+		if err != nil {
+			return nil, err
+		}
+		privateKey.Primes = []*big.Int{p, q}
+		if err := privateKey.Validate(); err != nil {
+			return nil, err
+		}
+		privateKey.Precompute()
+		return privateKey, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case ECDSA_P256:
+			curve = elliptic.P256()
+		case ECDSA_P348:
+			curve = elliptic.P384()
+		case ECDSA_P521:
+			curve = elliptic.P521()
+		default:
+			return nil, errors.New("Unsupported curve: " + j.Crv)
+		}
+		publicKey := &ecdsa.PublicKey{Curve: curve}
+		size := curveSize(publicKey)
+		var err error
+		if publicKey.X, err = decodeFixed(j.X, size); err != nil {
+			return nil, err
+		}
+		if publicKey.Y, err = decodeFixed(j.Y, size); err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return nil, errors.New("Invalid EC public key")
+		}
+		if j.D == "" {
+			return publicKey, nil
+		}
+		d, err := decodeFixed(j.D, size)
+		if err != nil {
+			return nil, err
+		}
+		x, y := curve.ScalarBaseMult(d.Bytes())
+		if x.Cmp(publicKey.X) != 0 || y.Cmp(publicKey.Y) != 0 {
+			return nil, errors.New("EC private key does not match the public key")
+		}
+		return &ecdsa.PrivateKey{PublicKey: *publicKey, D: d}, nil
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, errors.New("Unsupported curve: " + j.Crv)
+		}
+		x, err := base64URL.DecodeString(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("Invalid Ed25519 public key")
+		}
+		if j.D == "" {
+			return ed25519.PublicKey(x), nil
+		}
+		seed, err := base64URL.DecodeString(j.D)
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, errors.New("Invalid Ed25519 private key")
+		}
+		privateKey := ed25519.NewKeyFromSeed(seed)
+		if !privateKey.Public().(ed25519.PublicKey).Equal(ed25519.PublicKey(x)) {
+			return nil, errors.New("Ed25519 private key does not match the public key")
+		}
+		return privateKey, nil
+	case "oct":
+		k, err := base64URL.DecodeString(j.K)
+		if err != nil || len(k) == 0 {
+			return nil, errors.New("Invalid secret")
+		}
+		return k, nil
+	}
+	return nil, errors.New("Unsupported key type: " + j.Kty)
+}
+
+// Algorithm creates an algorithm from the key. If name is empty, the "alg" member is used.
+// Public keys can only verify signatures.
+func (j *JWK) Algorithm(name string) (Algorithm, error) {
+	if name == "" {
+		name = j.Alg
+	}
+	if j.Alg != "" && j.Alg != name {
+		return nil, errors.New("Key is intended for " + j.Alg + " and can't be used for " + name)
+	}
+	key, err := j.Key()
+	if err != nil {
+		return nil, err
+	}
+	return NewAlgorithm(name, key)
+}
+
+// compatible reports whether the key type can be used with the algorithm.
+func (j *JWK) compatible(name string) bool {
+	switch name {
+	case JWT_HS256, JWT_HS348, JWT_HS512:
+		return j.Kty == "oct"
+	case JWT_RS256, JWT_RS384, JWT_RS512, JWT_PS256, JWT_PS348, JWT_PS512:
+		return j.Kty == "RSA"
+	case JWT_ES256, JWT_ES348, JWT_ES512:
+		return j.Kty == "EC" && j.Crv == JWT_ECDS_MAP[name]
+	case JWT_EdDSA:
+		return j.Kty == "OKP" && j.Crv == "Ed25519"
+	}
+	return false
+}
+
+// JWKS represents a JWK set (RFC 7517 section 5).
+type JWKS struct {
+	Keys []*JWK `json:"keys"`
+}
+
+// KeySet creates a key set from the signature keys of the JWK set. The algorithm of a key
+// is its "alg" member, or the first of the algorithms which can be used with the key type.
+// If algorithms are given, the key set only accepts these. Keys without "kid" are
+// identified by their position. Keys which can't be used are skipped.
+func (s *JWKS) KeySet(algorithms ...string) (*KeySet, error) {
+	keys, err := s.keys(algorithms)
+	if err != nil {
+		return nil, err
+	}
+	set := NewKeySet(keys...)
+	set.AllowAlgorithms(algorithms...)
+	return set, nil
+}
+
+func (s *JWKS) keys(algorithms []string) ([]*Key, error) {
+	keys := []*Key{}
+	for i, jwk := range s.Keys {
+		if jwk == nil || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		name := jwk.Alg
+		if name == "" {
+			for _, candidate := range algorithms {
+				if jwk.compatible(candidate) {
+					name = candidate
+					break
+				}
+			}
+		} else if len(algorithms) > 0 && !contains(algorithms, name) {
+			continue
+		}
+		if !jwk.compatible(name) {
+			continue
+		}
+		algorithm, err := jwk.Algorithm(name)
+		if err != nil {
+			continue
+		}
+		id := jwk.Kid
+		if id == "" {
+			id = strconv.Itoa(i)
+		}
+		key, err := NewKey(id, algorithm)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWK set contains no usable keys")
+	}
+	return keys, nil
+}
+
+// Public returns a JWK set with the public keys of the set. Symmetric keys are omitted.
+func (s *JWKS) Public() *JWKS {
+	public := &JWKS{Keys: []*JWK{}}
+	for _, jwk := range s.Keys {
+		if key := jwk.Public(); key != nil {
+			public.Keys = append(public.Keys, key)
+		}
+	}
+	return public
+}
+
+// ServeHTTP serves the public keys of the set.
+func (s *JWKS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Public())
+}
+
+func curveSize(key *ecdsa.PublicKey) int {
+	return (key.Curve.Params().BitSize + 7) / 8
+}
+
+func encodeInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func decodeInt(value string) (*big.Int, error) {
+	data, err := base64URL.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("Invalid JWK member")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+func decodeFixed(value string, size int) (*big.Int, error) {
+	data, err := base64URL.DecodeString(value)
+	if err != nil || len(data) != size {
+		return nil, errors.New("Invalid JWK member")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+func encodeFixed(i *big.Int, size int) string {
+	return base64.RawURLEncoding.EncodeToString(i.FillBytes(make([]byte, size)))
+}
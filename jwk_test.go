@@ -0,0 +1,192 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+func TestJWKRSA(t *testing.T) {
+	fixture, _ := readFixture("rsa")
+	block, _ := pem.Decode(fixture)
+	privateKey, _ := x509.ParsePKCS1PrivateKey(block.Bytes)
+	jwk, err := NewJWK(privateKey)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if jwk.Kty != "RSA" || jwk.E != "AQAB" || jwk.D == "" || jwk.Qi == "" {
+		t.Log(jwk)
+		t.Fail()
+	}
+	public := jwk.Public()
+	if public.D != "" || public.P != "" || public.N != jwk.N {
+		t.Log(public)
+		t.Fail()
+	}
+}
+
+func TestJWKECDSA(t *testing.T) {
+	privateKey, _ := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	jwk, err := NewJWK(privateKey)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	// P-521 coordinates are 66 bytes long which is 88 base64url characters
+	if jwk.Crv != ECDSA_P521 || len(jwk.X) != 88 || len(jwk.Y) != 88 || len(jwk.D) != 88 {
+		t.Log(jwk)
+		t.Fail()
+	}
+	privateKey, _ = ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	_, err = NewJWK(privateKey)
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestJWKSecret(t *testing.T) {
+	jwk, err := NewJWK([]byte("secret"))
+	if err != nil || jwk.Kty != "oct" || jwk.K != "c2VjcmV0" || jwk.Public() != nil {
+		t.Log(err)
+		t.Fail()
+	}
+	_, err = NewJWK([]byte{})
+	if err == nil {
+		t.Fail()
+	}
+	_, err = NewJWK("invalid")
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestJWKKey(t *testing.T) {
+	for _, name := range []string{JWT_HS256, JWT_RS256, JWT_PS348, JWT_ES256, JWT_ES348, JWT_ES512, JWT_EdDSA} {
+		key, err := GenerateKey(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		token, _ := Create(&Claims{Subject: name}, key.Algorithm)
+		signer, err := key.PrivateJWK.Algorithm("")
+		if err != nil {
+			t.Log(name, err)
+			t.Fail()
+			continue
+		}
+		if _, err := Parse(token, signer); err != nil {
+			t.Log(name, err)
+			t.Fail()
+		}
+		if key.PublicJWK == nil {
+			continue
+		}
+		verifier, err := key.PublicJWK.Algorithm(name)
+		if err != nil {
+			t.Log(name, err)
+			t.Fail()
+			continue
+		}
+		if _, err := Parse(token, verifier); err != nil {
+			t.Log(name, err)
+			t.Fail()
+		}
+		if _, err := Create(&Claims{}, verifier); err == nil {
+			t.Log(name, "public key signed a token")
+			t.Fail()
+		}
+	}
+}
+
+func TestJWKKeyErrors(t *testing.T) {
+	key, _ := GenerateKey(JWT_ES256)
+	other, _ := GenerateKey(JWT_ES256)
+	mismatch := *key.PrivateJWK
+	mismatch.D = other.PrivateJWK.D
+	offCurve := *key.PublicJWK
+	offCurve.Y = offCurve.X
+	for _, jwk := range []*JWK{
+		{Kty: "RSA", N: "", E: "AQAB"},
+		{Kty: "RSA", N: "AQAB", E: "AQ"},
+		{Kty: "EC", Crv: "P-192"},
+		{Kty: "EC", Crv: ECDSA_P256, X: key.PublicJWK.X, Y: "AQAB"},
+		&offCurve,
+		&mismatch,
+		{Kty: "OKP", Crv: "X25519"},
+		{Kty: "OKP", Crv: "Ed25519", X: "AQAB"},
+		{Kty: "oct"},
+		{Kty: "unknown"},
+	} {
+		if _, err := jwk.Key(); err == nil {
+			t.Log(jwk)
+			t.Fail()
+		}
+	}
+	if _, err := key.PublicJWK.Algorithm(JWT_ES512); err == nil {
+		t.Fail()
+	}
+}
+
+func TestJWKSKeySet(t *testing.T) {
+	rsa, _ := GenerateKey(JWT_RS256)
+	ec, _ := GenerateKey(JWT_ES256)
+	ed, _ := GenerateKey(JWT_EdDSA)
+	rsa.PublicJWK.Kid = "rsa"
+	rsa.PublicJWK.Alg = ""
+	ec.PublicJWK.Kid = "ec"
+	encryption := *ec.PublicJWK
+	encryption.Kid = "enc"
+	encryption.Use = "enc"
+	jwks := &JWKS{Keys: []*JWK{rsa.PublicJWK, ec.PublicJWK, ed.PublicJWK, &encryption, {Kty: "RSA"}}}
+	keys, err := jwks.KeySet(JWT_PS256, JWT_ES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys.Keys()) != 2 || keys.Keys()[0].Name() != JWT_PS256 || keys.Keys()[1].ID != "ec" {
+		t.Log(keys.Keys())
+		t.Fail()
+	}
+	all, _ := jwks.KeySet()
+	if len(all.Keys()) != 2 || all.Keys()[1].ID != "2" {
+		t.Log(all.Keys())
+		t.Fail()
+	}
+	token, _ := Create(&Claims{}, &Key{ec.Algorithm, "ec"})
+	if _, err := ParseWithKeySet(token, keys); err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+	if _, err := (&JWKS{}).KeySet(); err == nil {
+		t.Fail()
+	}
+	if public := (&JWKS{Keys: []*JWK{rsa.PrivateJWK, {Kty: "oct", K: "AQAB"}}}).Public(); len(public.Keys) != 1 || public.Keys[0].IsPrivate() {
+		t.Log(public.Keys)
+		t.Fail()
+	}
+}
@@ -27,36 +27,50 @@ import (
 	base64 "encoding/base64"
 	"encoding/json"
 	"errors"
-	"strings"
 	"time"
 )
 
+// The identifiers JWT_ES348, JWT_HS348 and JWT_PS348 are kept for compatibility. Their values
+// are the registered algorithm names (RFC 7518 section 3.1).
 const (
 	JWT_ES256 = "ES256"
-	JWT_ES348 = "ES348"
+	JWT_ES348 = "ES384"
 	JWT_ES512 = "ES512"
+	JWT_EdDSA = "EdDSA"
 	JWT_HS256 = "HS256"
-	JWT_HS348 = "HS348"
+	JWT_HS348 = "HS384"
 	JWT_HS512 = "HS512"
 	JWT_PS256 = "PS256"
-	JWT_PS348 = "PS348"
+	JWT_PS348 = "PS384"
 	JWT_PS512 = "PS512"
 	JWT_RS256 = "RS256"
 	JWT_RS384 = "RS384"
 	JWT_RS512 = "RS512"
+	JWT_NONE  = "none"
+)
+
+var (
+	ErrInvalidTokenFormat  = errors.New("Invalid token format")
+	ErrInvalidAlgorithm    = errors.New("Invalid JWT algorithm")
+	ErrUnknownAlgorithm    = errors.New("Unknown JWT algorithm")
+	ErrAlgorithmNone       = errors.New("Unsecured JWTs are not accepted")
+	ErrAlgorithmNotAllowed = errors.New("JWT algorithm is not allowed")
+	ErrInvalidSignature    = errors.New("Invalid signature")
 )
 
 var algorithms = []string{
-	JWT_ES256, JWT_ES348, JWT_ES512, JWT_HS256, JWT_HS348, JWT_HS512,
+	JWT_ES256, JWT_ES348, JWT_ES512, JWT_EdDSA, JWT_HS256, JWT_HS348, JWT_HS512,
 	JWT_PS256, JWT_PS348, JWT_PS512, JWT_RS256, JWT_RS384, JWT_RS512,
 }
 
 // Algorithm representing one of the supported JWT alogrithms:
-// ECDSA-SHA:        ES256, ES348, ES512
-// HMAC-SHA:         HS256, HS348, HS512
-// RSASSA-PSS-SHA:   PS256, PS348, PS512
+// ECDSA-SHA:        ES256, ES384, ES512
+// EdDSA:            EdDSA (Ed25519)
+// HMAC-SHA:         HS256, HS384, HS512
+// RSASSA-PSS-SHA:   PS256, PS384, PS512
 // RSASSA-PKCS1-SHA: RS256, RS384, RS512
-// None is not supported
+// None is only supported with the DangerouslyAllowUnsecured option
+// The algorithms of this package may be used from multiple goroutines.
 type Algorithm interface {
 	Sign([]byte) ([]byte, error)
 	Verify([]byte, []byte) error
@@ -65,19 +79,91 @@ type Algorithm interface {
 
 // JwtCkaims represents JWT standard claims
 type Claims struct {
-	Expires   int64                  `json:"exp"`
-	IssuedAt  int64                  `json:"iat"`
-	NotBefore int64                  `json:"nbf"`
-	Subject   string                 `json:"sub"`
-	Audience  string                 `json:"aud"`
-	Issuer    string                 `json:"iss"`
+	Expires   int64                  `json:"exp,omitempty"`
+	IssuedAt  int64                  `json:"iat,omitempty"`
+	NotBefore int64                  `json:"nbf,omitempty"`
+	Subject   string                 `json:"sub,omitempty"`
+	Audience  string                 `json:"aud,omitempty"`
+	Issuer    string                 `json:"iss,omitempty"`
 	Raw       map[string]interface{} `json:"-"`
 }
 
+// standardClaims is used for encoding Claims without recursing into MarshalJSON.
+type standardClaims Claims
+
+// MarshalJSON encodes the standard claims together with the custom claims in Raw.
+// Standard claims which are set take precedence over entries in Raw with the same name.
+func (c Claims) MarshalJSON() ([]byte, error) {
+	standard, err := json.Marshal(standardClaims(c))
+	if err != nil {
+		return nil, err
+	}
+	return mergeJSON(standard, c.Raw)
+}
+
+// UnmarshalJSON decodes the standard claims and stores all claims in Raw.
+func (c *Claims) UnmarshalJSON(data []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	claims, err := claimsFromMap(raw)
+	if err != nil {
+		return err
+	}
+	*c = claims
+	return nil
+}
+
 // JwtHeader represents a JWT header
 type JwtHeader struct {
-	Alg string `json:"alg"`
-	Typ string `json:"typ"`
+	Alg  string                 `json:"alg"`
+	Typ  string                 `json:"typ"`
+	Kid  string                 `json:"kid,omitempty"`
+	Crit []string               `json:"crit,omitempty"`
+	Raw  map[string]interface{} `json:"-"`
+}
+
+// standardHeader is used for encoding JwtHeader without recursing into MarshalJSON.
+type standardHeader JwtHeader
+
+// MarshalJSON encodes the standard header parameters together with the custom parameters in Raw.
+// Standard parameters take precedence over entries in Raw with the same name.
+func (h JwtHeader) MarshalJSON() ([]byte, error) {
+	standard, err := json.Marshal(standardHeader(h))
+	if err != nil {
+		return nil, err
+	}
+	return mergeJSON(standard, h.Raw)
+}
+
+// UnmarshalJSON decodes the standard header parameters and stores all parameters in Raw.
+func (h *JwtHeader) UnmarshalJSON(data []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	header, err := headerFromMap(raw)
+	if err != nil {
+		return err
+	}
+	*h = header
+	return nil
+}
+
+// mergeJSON adds the members of raw to the encoded JSON object unless already present.
+func mergeJSON(standard []byte, raw map[string]interface{}) ([]byte, error) {
+	if len(raw) == 0 {
+		return standard, nil
+	}
+	merged := map[string]interface{}{}
+	for name, value := range raw {
+		merged[name] = value
+	}
+	if err := json.Unmarshal(standard, &merged); err != nil {
+		return nil, err
+	}
+	return json.Marshal(merged)
 }
 
 // JwtToken represents a JWT token
@@ -88,18 +174,40 @@ type JwtToken struct {
 }
 
 // CreateToken returns a JWT. First argument is the claims, second the private key.
-func Create(claims *Claims, algorithm Algorithm) (string, error) {
+func Create(claims *Claims, algorithm Algorithm, opts ...Option) (string, error) {
 	if claims == nil {
 		claims = &Claims{}
 	}
 	if algorithm == nil {
 		return "", errors.New("Algorithm can't be nil")
 	}
+	o := newOptions(opts)
+	if err := checkUnsecured(algorithm, o); err != nil {
+		return "", err
+	}
 	jwtHeader := &JwtHeader{
 		Alg: algorithm.Name(),
 		Typ: "jwt",
 	}
-	header, _ := json.Marshal(jwtHeader)
+	if o.typ != "" {
+		jwtHeader.Typ = o.typ
+	}
+	if key, ok := algorithm.(*Key); ok {
+		jwtHeader.Kid = key.ID
+	} else if o.thumbprintKid {
+		kid, err := algorithmThumbprint(algorithm)
+		if err != nil {
+			return "", err
+		}
+		jwtHeader.Kid = kid
+	}
+	if err := o.applyHeaders(jwtHeader); err != nil {
+		return "", err
+	}
+	header, err := json.Marshal(jwtHeader)
+	if err != nil {
+		return "", err
+	}
 	encodedHeader := base64.RawURLEncoding.EncodeToString(header)
 
 	claims.IssuedAt = time.Now().Unix()
@@ -124,66 +232,28 @@ func Create(claims *Claims, algorithm Algorithm) (string, error) {
 }
 
 // Parse parses a JWT token from a string.
-func Parse(token string, alg Algorithm) (*JwtToken, error) {
-	splitted := strings.Split(token, ".")
-	if len(splitted) != 3 {
-		return nil, errors.New("Invalid token format")
-	}
-
-	encodedHeader := splitted[0]
-	header, err := base64.RawURLEncoding.DecodeString(encodedHeader)
-	if err != nil {
-		return nil, err
-	}
-	var jwtHeader JwtHeader
-	err = json.Unmarshal(header, &jwtHeader)
-	if err != nil {
-		return nil, err
-	}
-	ok := false
-	for _, accepted := range algorithms {
-		if jwtHeader.Alg == accepted {
-			ok = true
+func Parse(token string, alg Algorithm, opts ...Option) (*JwtToken, error) {
+	o := newOptions(opts)
+	if o.cache != nil {
+		cached := o.cache.get(token, o, func(entry *cacheEntry) bool {
+			return entry.algorithm == alg
+		})
+		if cached != nil {
+			return cached, nil
 		}
 	}
-	if !ok {
-		return nil, errors.New("Invalid JWT algorithm")
-	}
-	if jwtHeader.Alg != alg.Name() {
-		return nil, errors.New("Invalid JWT algorithm")
-	}
-
-	encodedPayload := splitted[1]
-	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	unverified, err := decode(token, false, o)
 	if err != nil {
 		return nil, err
 	}
-
-	encodedSignature := splitted[2]
-	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	verified, err := unverified.verify(alg, o)
 	if err != nil {
 		return nil, err
 	}
-
-	headerAndPayload := []byte(encodedHeader + "." + encodedPayload)
-	if err = alg.Verify(headerAndPayload, signature); err != nil {
-		return nil, errors.New("Invalid signature")
+	if o.cache != nil {
+		o.cache.put(token, verified, alg, o)
 	}
-
-	var claims Claims
-	err = json.Unmarshal(payload, &claims)
-	if err != nil {
-		return nil, err
-	}
-
-	var rawClaims map[string]interface{}
-	err = json.Unmarshal(payload, &rawClaims)
-	if err != nil {
-		return nil, err
-	}
-	claims.Raw = rawClaims
-
-	return &JwtToken{jwtHeader, claims, signature}, nil
+	return verified, nil
 }
 
 // IsExpired checks if a token is expired.
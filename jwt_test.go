@@ -241,3 +241,67 @@ func writeFixture(file string, data []byte) error {
 	filePath := path.Join(pwd, "fixtures", file)
 	return os.WriteFile(filePath, data, os.ModePerm)
 }
+
+func TestCreateCustomClaims(t *testing.T) {
+	alg, _ := NewHS256(testSecret("secret"))
+	claims := &Claims{
+		Expires: time.Now().Add(time.Hour).Unix(),
+		Subject: "subject",
+		Raw:     map[string]interface{}{"scope": "read", "sub": "ignored"},
+	}
+	tokenString, err := Create(claims, alg)
+	if err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+	token, err := Parse(tokenString, alg)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if token.Claims.Subject != "subject" || token.Claims.Raw["sub"] != "subject" || token.Claims.Raw["scope"] != "read" {
+		t.Log(token.Claims.Raw)
+		t.Fail()
+	}
+}
+
+func TestCreateOmitsUnsetClaims(t *testing.T) {
+	alg, _ := NewHS256(testSecret("secret"))
+	tokenString, _ := Create(&Claims{Raw: map[string]interface{}{"aud": []string{"a", "b"}}}, alg)
+	token, err := Parse(tokenString, alg)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	for _, name := range []string{"exp", "nbf", "sub", "iss"} {
+		if _, ok := token.Claims.Raw[name]; ok {
+			t.Log(name, token.Claims.Raw)
+			t.Fail()
+		}
+	}
+	if len(token.Claims.Audiences()) != 2 {
+		t.Log(token.Claims.Raw)
+		t.Fail()
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	alg, _ := NewHS256(testSecret("secret"))
+	other, _ := NewHS512(testSecret("secret"))
+	tokenString, _ := Create(&Claims{}, alg)
+	_, err := Parse("a.b", alg)
+	if err != ErrInvalidTokenFormat {
+		t.Log(err)
+		t.Fail()
+	}
+	_, err = Parse(tokenString, other)
+	if err != ErrInvalidAlgorithm {
+		t.Log(err)
+		t.Fail()
+	}
+	_, err = Parse(tokenString+"x", alg)
+	if err == nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
@@ -0,0 +1,179 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+)
+
+const (
+	// RSA_DEFAULT_BITS is the size of RSA keys created by GenerateKey.
+	RSA_DEFAULT_BITS = 2048
+	// RSA_MIN_BITS is the smallest RSA key size accepted by GenerateRSAKey.
+	RSA_MIN_BITS = 2048
+)
+
+// GeneratedKey holds a generated key, an algorithm using it and its exports.
+// Secret is only set for HMAC keys. HMAC keys have no PEM exports and no public half.
+type GeneratedKey struct {
+	Algorithm     Algorithm
+	Secret        []byte
+	PrivateKeyPEM []byte
+	PublicKeyPEM  []byte
+	PrivateJWK    *JWK
+	PublicJWK     *JWK
+}
+
+// GenerateKey generates a key for the JWT algorithm. RSA keys have RSA_DEFAULT_BITS bits.
+func GenerateKey(name string) (*GeneratedKey, error) {
+	switch name {
+	case JWT_HS256:
+		return generateHMAC(name, 32)
+	case JWT_HS348:
+		return generateHMAC(name, 48)
+	case JWT_HS512:
+		return generateHMAC(name, 64)
+	case JWT_RS256, JWT_RS384, JWT_RS512, JWT_PS256, JWT_PS348, JWT_PS512:
+		return generateRSA(name, RSA_DEFAULT_BITS)
+	case JWT_ES256:
+		return generateECDSA(name, elliptic.P256())
+	case JWT_ES348:
+		return generateECDSA(name, elliptic.P384())
+	case JWT_ES512:
+		return generateECDSA(name, elliptic.P521())
+	case JWT_EdDSA:
+		return generateEdDSA()
+	}
+	return nil, errors.New("Unsupported JWT algorithm: " + name)
+}
+
+// GenerateRSAKey generates an RSA key of the given size for one of the RS and PS algorithms.
+func GenerateRSAKey(name string, bits int) (*GeneratedKey, error) {
+	switch name {
+	case JWT_RS256, JWT_RS384, JWT_RS512, JWT_PS256, JWT_PS348, JWT_PS512:
+		return generateRSA(name, bits)
+	}
+	return nil, errors.New("Not an RSA algorithm: " + name)
+}
+
+func generateHMAC(name string, size int) (*GeneratedKey, error) {
+	secret := make([]byte, size)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	algorithm, err := NewAlgorithm(name, secret)
+	if err != nil {
+		return nil, err
+	}
+	jwk, _ := NewJWK(secret)
+	jwk.Alg = name
+	return &GeneratedKey{Algorithm: algorithm, Secret: secret, PrivateJWK: jwk}, nil
+}
+
+func generateRSA(name string, bits int) (*GeneratedKey, error) {
+	if bits < RSA_MIN_BITS {
+		return nil, errors.New("RSA keys must have at least 2048 bits")
+	}
+	privateKey, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return nil, err
+	}
+	algorithm, err := NewAlgorithm(name, privateKey)
+	if err != nil {
+		return nil, err
+	}
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&privateKey.PublicKey)})
+	return newGeneratedKey(name, algorithm, privateKey, privatePEM, publicPEM)
+}
+
+func generateECDSA(name string, curve elliptic.Curve) (*GeneratedKey, error) {
+	privateKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	algorithm, err := NewAlgorithm(name, privateKey)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalECPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	publicPEM, err := publicKeyPEM(&privateKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return newGeneratedKey(name, algorithm, privateKey, privatePEM, publicPEM)
+}
+
+func generateEdDSA() (*GeneratedKey, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	publicPEM, err := publicKeyPEM(privateKey.Public())
+	if err != nil {
+		return nil, err
+	}
+	return newGeneratedKey(JWT_EdDSA, newEdDSAFromKey(privateKey), privateKey, privatePEM, publicPEM)
+}
+
+func publicKeyPEM(publicKey crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+func newGeneratedKey(name string, algorithm Algorithm, privateKey crypto.PrivateKey, privatePEM, publicPEM []byte) (*GeneratedKey, error) {
+	privateJWK, err := NewJWK(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	privateJWK.Alg = name
+	privateJWK.Use = "sig"
+	return &GeneratedKey{
+		Algorithm:     algorithm,
+		PrivateKeyPEM: privatePEM,
+		PublicKeyPEM:  publicPEM,
+		PrivateJWK:    privateJWK,
+		PublicJWK:     privateJWK.Public(),
+	}, nil
+}
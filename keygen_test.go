@@ -0,0 +1,165 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+var generatedAlgorithms = []string{
+	JWT_ES256, JWT_ES348, JWT_ES512, JWT_EdDSA, JWT_HS256, JWT_HS348, JWT_HS512,
+	JWT_PS256, JWT_PS348, JWT_PS512, JWT_RS256, JWT_RS384, JWT_RS512,
+}
+
+func newAlgorithmFromPEM(name string, key []byte) (Algorithm, error) {
+	switch name {
+	case JWT_ES256:
+		return NewES256(key)
+	case JWT_ES348:
+		return NewES384(key)
+	case JWT_ES512:
+		return NewES512(key)
+	case JWT_EdDSA:
+		return NewEdDSA(key)
+	case JWT_PS256:
+		return NewPS256(key)
+	case JWT_PS348:
+		return NewPS384(key)
+	case JWT_PS512:
+		return NewPS512(key)
+	case JWT_RS256:
+		return NewRS256(key)
+	case JWT_RS384:
+		return NewRS384(key)
+	}
+	return NewRS512(key)
+}
+
+func TestGenerateKey(t *testing.T) {
+	for _, name := range generatedAlgorithms {
+		key, err := GenerateKey(name)
+		if err != nil {
+			t.Log(name, err)
+			t.Fail()
+			continue
+		}
+		if key.Algorithm.Name() != name || key.PrivateJWK.Alg != name {
+			t.Log(name)
+			t.Fail()
+		}
+		err = CheckTokenFor(key.Algorithm, t)
+		if err != nil {
+			t.Log(name, err)
+			t.Fail()
+		}
+		if key.Secret != nil {
+			if len(key.PrivateKeyPEM) != 0 || key.PublicJWK != nil || key.PrivateJWK.Kty != "oct" {
+				t.Log(name)
+				t.Fail()
+			}
+			continue
+		}
+		algorithm, err := newAlgorithmFromPEM(name, key.PrivateKeyPEM)
+		if err != nil {
+			t.Log(name, err)
+			t.Fail()
+			continue
+		}
+		signature, _ := key.Algorithm.Sign([]byte("test"))
+		if err := algorithm.Verify([]byte("test"), signature); err != nil {
+			t.Log(name, err)
+			t.Fail()
+		}
+		if len(key.PublicKeyPEM) == 0 || key.PublicJWK.IsPrivate() || !key.PrivateJWK.IsPrivate() {
+			t.Log(name)
+			t.Fail()
+		}
+		encoded, _ := json.Marshal(key.PublicJWK)
+		if len(encoded) == 0 || key.PublicJWK.Use != "sig" {
+			t.Log(name)
+			t.Fail()
+		}
+	}
+}
+
+func TestGenerateRSAKey(t *testing.T) {
+	key, err := GenerateRSAKey(JWT_RS256, 3072)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if len(key.PublicJWK.N) != 512 {
+		t.Log(len(key.PublicJWK.N))
+		t.Fail()
+	}
+	_, err = GenerateRSAKey(JWT_RS256, 1024)
+	if err == nil {
+		t.Fail()
+	}
+	_, err = GenerateRSAKey(JWT_ES256, 3072)
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestNewVerifierFromPEM(t *testing.T) {
+	for _, name := range []string{JWT_RS256, JWT_PS512, JWT_ES348, JWT_EdDSA} {
+		key, _ := GenerateKey(name)
+		verifier, err := NewVerifierFromPEM(name, key.PublicKeyPEM)
+		if err != nil {
+			t.Log(name, err)
+			t.Fail()
+			continue
+		}
+		signature, _ := key.Algorithm.Sign([]byte("data"))
+		if err := verifier.Verify([]byte("data"), signature); err != nil {
+			t.Log(name, err)
+			t.Fail()
+		}
+		if _, err := verifier.Sign([]byte("data")); err != ErrVerifyOnly {
+			t.Log(name, err)
+			t.Fail()
+		}
+	}
+	for name, fixture := range map[string]string{JWT_RS256: "rsa.pub", JWT_ES256: "ecdsa_256.pub"} {
+		if _, err := NewVerifierFromPEM(name, mustReadFixture(fixture)); err != nil {
+			t.Log(name, err)
+			t.Fail()
+		}
+	}
+	for name, key := range map[string][]byte{JWT_RS256: mustReadFixture("rsa"), JWT_ES256: mustReadFixture("rsa.pub"), JWT_HS256: []byte("secret")} {
+		if _, err := NewVerifierFromPEM(name, key); err == nil {
+			t.Log(name)
+			t.Fail()
+		}
+	}
+}
+
+func TestGenerateKeyUnsupported(t *testing.T) {
+	_, err := GenerateKey("none")
+	if err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,182 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+var ErrKeyNotFound = errors.New("No key found for token")
+
+// Key binds an algorithm to a key identifier. Tokens created with a Key carry the
+// identifier in the "kid" header.
+type Key struct {
+	Algorithm
+	ID string
+}
+
+// NewKey creates a new key from a key identifier and an algorithm.
+func NewKey(id string, algorithm Algorithm) (*Key, error) {
+	if algorithm == nil {
+		return nil, errors.New("Algorithm can't be nil")
+	}
+	if id == "" {
+		return nil, errors.New("Key ID can't be empty")
+	}
+	if strings.EqualFold(algorithm.Name(), JWT_NONE) {
+		return nil, ErrAlgorithmNone
+	}
+	return &Key{algorithm, id}, nil
+}
+
+// KeySet holds multiple keys. Tokens are verified with the key matching their "kid" header.
+// A KeySet may be used from multiple goroutines.
+type KeySet struct {
+	mutex   sync.RWMutex
+	keys    []*Key
+	allowed []string
+}
+
+// NewKeySet creates a new key set.
+func NewKeySet(keys ...*Key) *KeySet {
+	set := &KeySet{}
+	for _, key := range keys {
+		set.Add(key)
+	}
+	return set
+}
+
+// Add adds a key to the set. A key with the same ID is replaced.
+func (s *KeySet) Add(key *Key) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.add(key)
+}
+
+func (s *KeySet) add(key *Key) {
+	for i, k := range s.keys {
+		if k.ID == key.ID {
+			s.keys[i] = key
+			return
+		}
+	}
+	s.keys = append(s.keys, key)
+}
+
+// Replace replaces all keys of the set, e.g. after reloading a JWK set.
+func (s *KeySet) Replace(keys ...*Key) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.keys = []*Key{}
+	for _, key := range keys {
+		s.add(key)
+	}
+}
+
+// Remove removes the key with the given ID from the set.
+func (s *KeySet) Remove(id string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i, k := range s.keys {
+		if k.ID == id {
+			s.keys = append(s.keys[:i], s.keys[i+1:]...)
+			return
+		}
+	}
+}
+
+// AllowAlgorithms restricts the algorithms accepted by the set. Tokens with other algorithms
+// are rejected with ErrAlgorithmNotAllowed even if the set contains a matching key. Calling
+// it without arguments lifts the restriction.
+func (s *KeySet) AllowAlgorithms(names ...string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.allowed = append([]string{}, names...)
+}
+
+// Keys returns the keys in the set.
+func (s *KeySet) Keys() []*Key {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return append([]*Key{}, s.keys...)
+}
+
+// Lookup returns the key for a token header. If the header has no "kid", the only key
+// with a matching algorithm is returned. Every key is bound to the algorithm it was
+// created with, a key is never used for a different algorithm.
+func (s *KeySet) Lookup(header *JwtHeader) (*Key, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if len(s.allowed) > 0 && !contains(s.allowed, header.Alg) {
+		return nil, ErrAlgorithmNotAllowed
+	}
+	var found *Key
+	for _, key := range s.keys {
+		if header.Kid != "" && key.ID != header.Kid {
+			continue
+		}
+		if key.Name() != header.Alg {
+			continue
+		}
+		if found != nil {
+			return nil, errors.New("Multiple keys found for token")
+		}
+		found = key
+	}
+	if found == nil {
+		return nil, ErrKeyNotFound
+	}
+	return found, nil
+}
+
+// ParseWithKeySet parses a JWT token from a string and verifies it with the matching key of the set.
+func ParseWithKeySet(token string, keys *KeySet, opts ...Option) (*JwtToken, error) {
+	if keys == nil {
+		return nil, errors.New("Key set can't be nil")
+	}
+	o := newOptions(opts)
+	if o.cache != nil {
+		cached := o.cache.get(token, o, func(entry *cacheEntry) bool {
+			key, err := keys.Lookup(&entry.token.Header)
+			return err == nil && Algorithm(key) == entry.algorithm
+		})
+		if cached != nil {
+			return cached, nil
+		}
+	}
+	unverified, err := decode(token, false, o)
+	if err != nil {
+		return nil, err
+	}
+	verified, key, err := unverified.verifyWithKeySet(keys, o)
+	if err != nil {
+		return nil, err
+	}
+	if o.cache != nil {
+		o.cache.put(token, verified, key, o)
+	}
+	return verified, nil
+}
@@ -0,0 +1,131 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestKeySet(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret1"))
+	hs512, _ := NewHS512(testSecret("secret2"))
+	key1, _ := NewKey("key1", hs256)
+	key2, _ := NewKey("key2", hs512)
+	set := NewKeySet(key1, key2)
+
+	tokenString, err := Create(&Claims{Expires: time.Now().Add(time.Hour).Unix()}, key2)
+	if err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+	token, err := ParseWithKeySet(tokenString, set)
+	if err != nil || token.Header.Kid != "key2" {
+		t.Log(err)
+		t.Fail()
+	}
+
+	set.Remove("key2")
+	_, err = ParseWithKeySet(tokenString, set)
+	if err != ErrKeyNotFound {
+		t.Log(err)
+		t.Fail()
+	}
+	if len(set.Keys()) != 1 {
+		t.Fail()
+	}
+}
+
+func TestKeySetWithoutKid(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret1"))
+	key, _ := NewKey("key1", hs256)
+	set := NewKeySet(key)
+	tokenString, _ := Create(&Claims{Expires: time.Now().Add(time.Hour).Unix()}, hs256)
+	_, err := ParseWithKeySet(tokenString, set)
+	if err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+	other, _ := NewHS256(testSecret("secret2"))
+	key, _ = NewKey("key2", other)
+	set.Add(key)
+	_, err = ParseWithKeySet(tokenString, set)
+	if err == nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestKeySetWrongKey(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret1"))
+	other, _ := NewHS256(testSecret("secret2"))
+	key1, _ := NewKey("key1", hs256)
+	key2, _ := NewKey("key1", other)
+	tokenString, _ := Create(&Claims{Expires: time.Now().Add(time.Hour).Unix()}, key1)
+	_, err := ParseWithKeySet(tokenString, NewKeySet(key2))
+	if err == nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestKeySetInvalid(t *testing.T) {
+	_, err := NewKey("", nil)
+	if err == nil {
+		t.Fail()
+	}
+	hs256, _ := NewHS256(testSecret("secret1"))
+	_, err = NewKey("", hs256)
+	if err == nil {
+		t.Fail()
+	}
+	_, err = ParseWithKeySet("a.b.c", nil)
+	if err == nil {
+		t.Fail()
+	}
+	for _, token := range []string{"", "!.a.a", "YQ.a.a"} {
+		_, err = ParseWithKeySet(token, NewKeySet())
+		if err == nil {
+			t.Log(token)
+			t.Fail()
+		}
+	}
+}
+
+func TestMiddlewareKeySet(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret1"))
+	key, _ := NewKey("key1", hs256)
+	raw, _ := Create(&Claims{Expires: time.Now().Add(time.Hour).Unix()}, key)
+	m := NewMiddleware(nil)
+	m.KeySet = NewKeySet(key)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+raw)
+	w, token := serve(m, r)
+	if w.Code != http.StatusOK || token == nil {
+		t.Log(w.Code)
+		t.Fail()
+	}
+}
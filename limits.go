@@ -0,0 +1,66 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"encoding/base64"
+	"errors"
+)
+
+var (
+	ErrTokenTooLarge     = errors.New("Token exceeds the size limit")
+	ErrJSONTooDeep       = errors.New("JSON exceeds the nesting depth limit")
+	ErrDuplicateJSONName = errors.New("JSON contains duplicate member names")
+	ErrInvalidJSON       = errors.New("Invalid JSON")
+)
+
+// base64URL decodes base64url without padding and rejects non-canonical encodings.
+var base64URL = base64.RawURLEncoding.Strict()
+
+// Limits restricts the size of tokens accepted by Parse and Decode. A zero value disables the limit.
+type Limits struct {
+	// MaxTokenLength is the maximum length of the encoded token.
+	MaxTokenLength int
+	// MaxHeaderSize is the maximum size of the decoded header.
+	MaxHeaderSize int
+	// MaxPayloadSize is the maximum size of the decoded payload.
+	MaxPayloadSize int
+	// MaxDepth is the maximum nesting depth of JSON objects and arrays.
+	MaxDepth int
+}
+
+// DefaultLimits are used if no limits are configured.
+var DefaultLimits = Limits{
+	MaxTokenLength: 64 * 1024,
+	MaxHeaderSize:  8 * 1024,
+	MaxPayloadSize: 48 * 1024,
+	MaxDepth:       32,
+}
+
+// WithLimits sets the limits for parsing tokens.
+func WithLimits(limits Limits) Option {
+	return func(o *options) {
+		o.limits = limits
+	}
+}
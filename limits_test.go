@@ -0,0 +1,212 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func encodeSegments(header, payload string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + base64.RawURLEncoding.EncodeToString([]byte(payload))
+}
+
+func TestLimitsTokenLength(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	token, _ := Create(&Claims{Raw: map[string]interface{}{"data": strings.Repeat("a", 100)}}, hs256)
+	_, err := Parse(token, hs256, WithLimits(Limits{MaxTokenLength: 100}))
+	if err != ErrTokenTooLarge {
+		t.Log(err)
+		t.Fail()
+	}
+	_, err = Parse(token, hs256, WithLimits(Limits{MaxPayloadSize: 100}))
+	if err != ErrTokenTooLarge {
+		t.Log(err)
+		t.Fail()
+	}
+	_, err = Parse(token, hs256, WithLimits(Limits{MaxHeaderSize: 10}))
+	if err != ErrTokenTooLarge {
+		t.Log(err)
+		t.Fail()
+	}
+	_, err = Parse(token, hs256, WithLimits(Limits{}))
+	if err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestLimitsDefault(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	token, _ := Create(&Claims{Raw: map[string]interface{}{"data": strings.Repeat("a", DefaultLimits.MaxTokenLength)}}, hs256)
+	_, err := Parse(token, hs256)
+	if err != ErrTokenTooLarge {
+		t.Log(err)
+		t.Fail()
+	}
+	_, err = Decode(token)
+	if err != ErrTokenTooLarge {
+		t.Log(err)
+		t.Fail()
+	}
+	_, err = ParseWithKeySet(token, NewKeySet())
+	if err != ErrTokenTooLarge {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestLimitsDepth(t *testing.T) {
+	deep := `{"a":` + strings.Repeat("[", 40) + strings.Repeat("]", 40) + `}`
+	_, err := Decode(encodeSegments(`{"alg":"HS256"}`, deep) + ".")
+	if err != ErrJSONTooDeep {
+		t.Log(err)
+		t.Fail()
+	}
+	_, err = Decode(encodeSegments(`{"alg":"HS256"}`, deep)+".", WithLimits(Limits{MaxDepth: 50}))
+	if err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestStrictJSON(t *testing.T) {
+	for _, payload := range []string{
+		`{"sub":"a","sub":"b"}`,
+		`{"sub":"a","sub":"b"}`,
+		`{"nested":{"a":1,"a":2}}`,
+		`{"list":[{"a":1,"a":2}]}`,
+		`{}{}`,
+		`{} x`,
+		`[]`,
+		`"string"`,
+		`{"a":}`,
+		`{"a":[1,}`,
+		``,
+	} {
+		_, err := Decode(encodeSegments(`{"alg":"HS256"}`, payload) + ".")
+		if err == nil {
+			t.Log(payload)
+			t.Fail()
+		}
+	}
+	_, err := Decode(encodeSegments(`{"alg":"HS256","alg":"none"}`, `{}`) + ".")
+	if err != ErrDuplicateJSONName {
+		t.Log(err)
+		t.Fail()
+	}
+	_, err = Decode(encodeSegments(`{"alg":"HS256"}`, `{"a":{"b":[1,{"c":null}]},"d":"e"} `) + ".")
+	if err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestStrictJSONNames(t *testing.T) {
+	many := make([]string, 100)
+	for i := range many {
+		many[i] = `"m` + strconv.Itoa(i) + `":` + strconv.Itoa(i)
+	}
+	for _, payload := range []string{
+		`{"sub":"a","s\u0075b":"b"}`,
+		`{"\u00e9":1,"é":2}`,
+		`{` + strings.Join(many, ",") + `,"m7":7}`,
+		`{"a":{` + strings.Join(many, ",") + `,"m99":0}}`,
+	} {
+		_, err := Decode(encodeSegments(`{"alg":"HS256"}`, payload) + ".")
+		if err != ErrDuplicateJSONName {
+			t.Log(payload, err)
+			t.Fail()
+		}
+	}
+	for _, payload := range []string{
+		`{"a":{"b":1},"c":{"b":1},"b":["b","b"]}`,
+		`{"a\"":1,"a":2}`,
+		`{` + strings.Join(many, ",") + `,"a":{` + strings.Join(many, ",") + `}}`,
+	} {
+		_, err := Decode(encodeSegments(`{"alg":"HS256"}`, payload) + ".")
+		if err != nil {
+			t.Log(payload, err)
+			t.Fail()
+		}
+	}
+}
+
+func TestStrictBase64(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	token, _ := Create(&Claims{}, hs256)
+	parts := strings.Split(token, ".")
+	for _, header := range []string{
+		parts[0] + "=",
+		"+" + parts[0][1:],
+		"e31",
+	} {
+		_, err := Decode(header + "." + parts[1] + "." + parts[2])
+		if err == nil {
+			t.Log(header)
+			t.Fail()
+		}
+	}
+	// "e30" is "{}", "e31" decodes to the same bytes with non-zero trailing bits
+	_, err := Decode("e30." + parts[1] + "." + parts[2])
+	if err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func FuzzDecode(f *testing.F) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	token, _ := Create(&Claims{Subject: "subject", Raw: map[string]interface{}{"scope": "read"}}, hs256)
+	f.Add(token)
+	f.Add("e30.e30.")
+	f.Add(encodeSegments(`{"alg":"HS256","a":[{}]}`, `{"a":[1,2,{"b":null}]}`) + ".")
+	f.Fuzz(func(t *testing.T, token string) {
+		unverified, err := Decode(token)
+		if err != nil {
+			return
+		}
+		if len(token) > DefaultLimits.MaxTokenLength {
+			t.Fatal("token exceeds limit")
+		}
+		if _, err := unverified.Verify(hs256); err == nil && unverified.Header.Alg != JWT_HS256 {
+			t.Fatal("token verified with wrong algorithm")
+		}
+	})
+}
+
+func FuzzParse(f *testing.F) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	token, _ := Create(&Claims{Subject: "subject"}, hs256)
+	f.Add(token)
+	f.Add("a.b.c")
+	f.Fuzz(func(t *testing.T, token string) {
+		parsed, err := Parse(token, hs256)
+		if err == nil && parsed.Header.Alg != JWT_HS256 {
+			t.Fatal("token parsed with wrong algorithm")
+		}
+	})
+}
@@ -0,0 +1,143 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"errors"
+	"math"
+)
+
+// claimsFromMap populates the standard claims from decoded JSON. Raw is set to the map.
+func claimsFromMap(raw map[string]interface{}) (Claims, error) {
+	claims := Claims{Raw: raw}
+	var err error
+	if claims.Expires, err = intMember(raw, "exp"); err != nil {
+		return Claims{}, err
+	}
+	if claims.IssuedAt, err = intMember(raw, "iat"); err != nil {
+		return Claims{}, err
+	}
+	if claims.NotBefore, err = intMember(raw, "nbf"); err != nil {
+		return Claims{}, err
+	}
+	if claims.Subject, err = stringMember(raw, "sub"); err != nil {
+		return Claims{}, err
+	}
+	if claims.Audience, err = audienceMember(raw); err != nil {
+		return Claims{}, err
+	}
+	if claims.Issuer, err = stringMember(raw, "iss"); err != nil {
+		return Claims{}, err
+	}
+	return claims, nil
+}
+
+// headerFromMap populates the standard header parameters from decoded JSON. Raw is set to the map.
+func headerFromMap(raw map[string]interface{}) (JwtHeader, error) {
+	header := JwtHeader{Raw: raw}
+	var err error
+	if header.Alg, err = stringMember(raw, "alg"); err != nil {
+		return JwtHeader{}, err
+	}
+	if header.Typ, err = stringMember(raw, "typ"); err != nil {
+		return JwtHeader{}, err
+	}
+	if header.Kid, err = stringMember(raw, "kid"); err != nil {
+		return JwtHeader{}, err
+	}
+	if header.Crit, err = stringsMember(raw, "crit"); err != nil {
+		return JwtHeader{}, err
+	}
+	return header, nil
+}
+
+// copy returns a copy of the header which does not share Crit and Raw.
+func (h JwtHeader) copy() JwtHeader {
+	if h.Crit != nil {
+		h.Crit = append([]string{}, h.Crit...)
+	}
+	if h.Raw != nil {
+		raw := make(map[string]interface{}, len(h.Raw))
+		for name, value := range h.Raw {
+			raw[name] = value
+		}
+		h.Raw = raw
+	}
+	return h
+}
+
+// audienceMember returns the "aud" claim, which may be a string or an array of strings
+// (RFC 7519 section 4.1.3). Arrays with more than one audience are only available through
+// Claims.Audiences.
+func audienceMember(raw map[string]interface{}) (string, error) {
+	if _, ok := raw["aud"].([]interface{}); !ok {
+		return stringMember(raw, "aud")
+	}
+	audiences, err := stringsMember(raw, "aud")
+	if err != nil || len(audiences) != 1 {
+		return "", err
+	}
+	return audiences[0], nil
+}
+
+func intMember(raw map[string]interface{}, name string) (int64, error) {
+	switch value := raw[name].(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		if value != math.Trunc(value) || value < math.MinInt64 || value >= math.MaxInt64 {
+			return 0, errors.New("Invalid value of " + name + ": not an integer")
+		}
+		return int64(value), nil
+	}
+	return 0, errors.New("Invalid value of " + name + ": not a number")
+}
+
+func stringMember(raw map[string]interface{}, name string) (string, error) {
+	switch value := raw[name].(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	}
+	return "", errors.New("Invalid value of " + name + ": not a string")
+}
+
+func stringsMember(raw map[string]interface{}, name string) ([]string, error) {
+	switch value := raw[name].(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		strs := make([]string, len(value))
+		for i, v := range value {
+			str, ok := v.(string)
+			if !ok {
+				return nil, errors.New("Invalid value of " + name + ": not an array of strings")
+			}
+			strs[i] = str
+		}
+		return strs, nil
+	}
+	return nil, errors.New("Invalid value of " + name + ": not an array")
+}
@@ -0,0 +1,211 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+var ErrTokenNotFound = errors.New("Token not found in request")
+
+// Extractor extracts a raw token from a request.
+// It returns ErrTokenNotFound if the request does not carry a token.
+type Extractor func(r *http.Request) (string, error)
+
+// BearerExtractor extracts a token from the Authorization header (RFC 6750 section 2.1).
+func BearerExtractor(r *http.Request) (string, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return "", ErrTokenNotFound
+	}
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		return "", ErrTokenNotFound
+	}
+	token := strings.TrimSpace(header[7:])
+	if token == "" {
+		return "", errors.New("Authorization header does not contain a token")
+	}
+	return token, nil
+}
+
+// CookieExtractor returns an extractor which reads the token from the named cookie.
+func CookieExtractor(name string) Extractor {
+	return func(r *http.Request) (string, error) {
+		cookie, err := r.Cookie(name)
+		if err != nil || cookie.Value == "" {
+			return "", ErrTokenNotFound
+		}
+		return cookie.Value, nil
+	}
+}
+
+// QueryExtractor returns an extractor which reads the token from the named query parameter.
+func QueryExtractor(name string) Extractor {
+	return func(r *http.Request) (string, error) {
+		token := r.URL.Query().Get(name)
+		if token == "" {
+			return "", ErrTokenNotFound
+		}
+		return token, nil
+	}
+}
+
+// Middleware authenticates HTTP requests with JWTs. Failed requests are rejected with
+// RFC 6750 compliant WWW-Authenticate headers.
+type Middleware struct {
+	// Algorithm verifies the token signature.
+	Algorithm Algorithm
+	// KeySet verifies the token signature with the key matching the "kid" header. It takes
+	// precedence over Algorithm.
+	KeySet *KeySet
+	// Extractors are tried in order until one finds a token.
+	Extractors []Extractor
+	// Validators are run after the signature and the time based claims have been checked.
+	Validators []Validator
+	// Realm is reported in the WWW-Authenticate header if not empty.
+	Realm string
+	// CertificateBound requires tokens to be bound to the TLS client certificate of the
+	// request (RFC 8705 section 3).
+	CertificateBound bool
+	// Cache skips the signature verification of recently verified tokens if not nil.
+	// Validators are run for every request.
+	Cache *Cache
+}
+
+// NewMiddleware creates a new middleware which reads bearer tokens from the Authorization header.
+func NewMiddleware(algorithm Algorithm, validators ...Validator) *Middleware {
+	return &Middleware{
+		Algorithm:  algorithm,
+		Extractors: []Extractor{BearerExtractor},
+		Validators: validators,
+	}
+}
+
+// Handler wraps an HTTP handler. The parsed token is stored in the request context and can be
+// retrieved with FromContext.
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, err := m.extract(r)
+		if err == ErrTokenNotFound {
+			m.challenge(w, http.StatusUnauthorized, "", "", nil)
+			return
+		}
+		if err != nil {
+			m.challenge(w, http.StatusBadRequest, "invalid_request", err.Error(), nil)
+			return
+		}
+		token, err := m.parse(r, raw)
+		if err != nil {
+			var scopeErr *InsufficientScopeError
+			if errors.As(err, &scopeErr) {
+				m.challenge(w, http.StatusForbidden, "insufficient_scope", err.Error(), scopeErr.Missing)
+				return
+			}
+			m.challenge(w, http.StatusUnauthorized, "invalid_token", err.Error(), nil)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), token)))
+	})
+}
+
+func (m *Middleware) extract(r *http.Request) (string, error) {
+	for _, extractor := range m.Extractors {
+		token, err := extractor(r)
+		if err == ErrTokenNotFound {
+			continue
+		}
+		return token, err
+	}
+	return "", ErrTokenNotFound
+}
+
+func (m *Middleware) parse(r *http.Request, raw string) (*JwtToken, error) {
+	var opts []Option
+	if m.Cache != nil {
+		opts = append(opts, WithCache(m.Cache))
+	}
+	validators := m.Validators
+	if m.CertificateBound {
+		validators = append([]Validator{ValidateTLSClientBinding(r)}, validators...)
+	}
+	return parseAndValidate(raw, m.Algorithm, m.KeySet, validators, opts)
+}
+
+// challenge writes an error response (RFC 6750 section 3).
+func (m *Middleware) challenge(w http.ResponseWriter, status int, code, description string, scope []string) {
+	params := []string{}
+	if m.Realm != "" {
+		params = append(params, `realm="`+quote(m.Realm)+`"`)
+	}
+	if code != "" {
+		params = append(params, `error="`+code+`"`)
+	}
+	if description != "" {
+		params = append(params, `error_description="`+quote(description)+`"`)
+	}
+	if len(scope) > 0 {
+		params = append(params, `scope="`+quote(strings.Join(scope, " "))+`"`)
+	}
+	challenge := "Bearer"
+	if len(params) > 0 {
+		challenge += " " + strings.Join(params, ", ")
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	http.Error(w, http.StatusText(status), status)
+}
+
+// quote removes characters which are not allowed in WWW-Authenticate parameter values.
+func quote(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' {
+			return -1
+		}
+		return r
+	}, value)
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx which carries the token.
+func NewContext(ctx context.Context, token *JwtToken) context.Context {
+	return context.WithValue(ctx, contextKey{}, token)
+}
+
+// FromContext returns the token stored in ctx by the middleware.
+func FromContext(ctx context.Context) (*JwtToken, bool) {
+	token, ok := ctx.Value(contextKey{}).(*JwtToken)
+	return token, ok && token != nil
+}
+
+// ClaimsFromContext returns the claims of the token stored in ctx by the middleware.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	token, ok := FromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	return &token.Claims, true
+}
@@ -0,0 +1,157 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestMiddleware(t *testing.T, validators ...Validator) (*Middleware, string) {
+	alg, _ := NewHS256(testSecret("secret"))
+	token, err := Create(&Claims{Expires: time.Now().Add(time.Hour).Unix(), Subject: "subject"}, alg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewMiddleware(alg, validators...), token
+}
+
+func serve(m *Middleware, r *http.Request) (*httptest.ResponseRecorder, *JwtToken) {
+	var token *JwtToken
+	handler := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, _ = FromContext(r.Context())
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w, token
+}
+
+func TestMiddlewareBearer(t *testing.T) {
+	m, raw := newTestMiddleware(t)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+raw)
+	w, token := serve(m, r)
+	if w.Code != http.StatusOK || token == nil || token.Claims.Subject != "subject" {
+		t.Log(w.Code)
+		t.Fail()
+	}
+}
+
+func TestMiddlewareCookieAndQuery(t *testing.T) {
+	m, raw := newTestMiddleware(t)
+	m.Extractors = []Extractor{BearerExtractor, CookieExtractor("token"), QueryExtractor("access_token")}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: "token", Value: raw})
+	w, token := serve(m, r)
+	if w.Code != http.StatusOK || token == nil {
+		t.Log(w.Code)
+		t.Fail()
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?access_token="+raw, nil)
+	w, token = serve(m, r)
+	if w.Code != http.StatusOK || token == nil {
+		t.Log(w.Code)
+		t.Fail()
+	}
+}
+
+func TestMiddlewareMissingToken(t *testing.T) {
+	m, _ := newTestMiddleware(t)
+	m.Realm = "example"
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	w, token := serve(m, r)
+	if w.Code != http.StatusUnauthorized || token != nil {
+		t.Log(w.Code)
+		t.Fail()
+	}
+	if w.Header().Get("WWW-Authenticate") != `Bearer realm="example"` {
+		t.Log(w.Header().Get("WWW-Authenticate"))
+		t.Fail()
+	}
+}
+
+func TestMiddlewareInvalidToken(t *testing.T) {
+	m, raw := newTestMiddleware(t)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+raw+"x")
+	w, token := serve(m, r)
+	if w.Code != http.StatusUnauthorized || token != nil {
+		t.Log(w.Code)
+		t.Fail()
+	}
+	if !strings.HasPrefix(w.Header().Get("WWW-Authenticate"), `Bearer error="invalid_token"`) {
+		t.Log(w.Header().Get("WWW-Authenticate"))
+		t.Fail()
+	}
+}
+
+func TestMiddlewareEmptyBearer(t *testing.T) {
+	m, _ := newTestMiddleware(t)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer ")
+	w, _ := serve(m, r)
+	if w.Code != http.StatusBadRequest {
+		t.Log(w.Code)
+		t.Fail()
+	}
+	if !strings.HasPrefix(w.Header().Get("WWW-Authenticate"), `Bearer error="invalid_request"`) {
+		t.Log(w.Header().Get("WWW-Authenticate"))
+		t.Fail()
+	}
+}
+
+func TestMiddlewareInsufficientScope(t *testing.T) {
+	m, raw := newTestMiddleware(t, func(*JwtToken) error {
+		return &InsufficientScopeError{Missing: []string{"read", "write"}}
+	})
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+raw)
+	w, token := serve(m, r)
+	if w.Code != http.StatusForbidden || token != nil {
+		t.Log(w.Code)
+		t.Fail()
+	}
+	challenge := w.Header().Get("WWW-Authenticate")
+	if !strings.Contains(challenge, `error="insufficient_scope"`) || !strings.Contains(challenge, `scope="read write"`) {
+		t.Log(challenge)
+		t.Fail()
+	}
+}
+
+func TestFromContextEmpty(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	_, ok := FromContext(r.Context())
+	if ok {
+		t.Fail()
+	}
+	_, ok = ClaimsFromContext(r.Context())
+	if ok {
+		t.Fail()
+	}
+}
@@ -0,0 +1,213 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"crypto"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidNonce           = errors.New("Invalid nonce")
+	ErrInvalidAuthorizedParty = errors.New("Invalid authorized party")
+	ErrAuthTimeTooOld         = errors.New("Authentication is too old")
+	ErrInvalidTokenHash       = errors.New("Invalid token hash")
+)
+
+// MissingClaimError is returned if a required claim is missing.
+type MissingClaimError struct {
+	Claim string
+}
+
+func (e *MissingClaimError) Error() string {
+	return "Missing claim: " + e.Claim
+}
+
+// IDTokenVerifier verifies OpenID Connect ID tokens (OpenID Connect Core 1.0 section 3.1.3.7).
+// The token must be issued by the issuer for the client and contain the "iss", "sub", "aud",
+// "exp" and "iat" claims. Tokens with multiple audiences must name the client in "azp".
+type IDTokenVerifier struct {
+	// Issuer is the expected "iss" claim.
+	Issuer string
+	// ClientID is the expected audience and authorized party.
+	ClientID string
+	// Algorithm verifies the token signature.
+	Algorithm Algorithm
+	// KeySet verifies the token signature with the key matching the "kid" header. It takes
+	// precedence over Algorithm.
+	KeySet *KeySet
+	// Options are applied when parsing the token.
+	Options []Option
+}
+
+// NewIDTokenVerifier creates a new ID token verifier.
+func NewIDTokenVerifier(issuer, clientID string, algorithm Algorithm) *IDTokenVerifier {
+	return &IDTokenVerifier{Issuer: issuer, ClientID: clientID, Algorithm: algorithm}
+}
+
+// Verify parses and validates an ID token. The validators are run after the checks of the
+// verifier, e.g. ValidateNonce, ValidateMaxAge, ValidateAccessTokenHash and ValidateCodeHash.
+func (v *IDTokenVerifier) Verify(raw string, validators ...Validator) (*JwtToken, error) {
+	if v.Issuer == "" || v.ClientID == "" {
+		return nil, errors.New("Issuer and client ID can't be empty")
+	}
+	checks := []Validator{
+		requireClaims("iss", "sub", "aud", "exp", "iat"),
+		ValidateIssuer(v.Issuer),
+		ValidateAudience(v.ClientID),
+		ValidateAuthorizedParty(v.ClientID),
+	}
+	return parseAndValidate(raw, v.Algorithm, v.KeySet, append(checks, validators...), v.Options)
+}
+
+// requireClaims returns a validator which checks that the claims are present. Time based
+// claims must not be zero.
+func requireClaims(names ...string) Validator {
+	return func(token *JwtToken) error {
+		claims := &token.Claims
+		for _, name := range names {
+			var present bool
+			switch name {
+			case "iss":
+				present = claims.Issuer != ""
+			case "sub":
+				present = claims.Subject != ""
+			case "aud":
+				present = len(claims.Audiences()) > 0
+			case "exp":
+				present = claims.Expires != 0
+			case "iat":
+				present = claims.IssuedAt != 0
+			case "nbf":
+				present = claims.NotBefore != 0
+			default:
+				_, present = claims.Raw[name]
+			}
+			if !present {
+				return &MissingClaimError{name}
+			}
+		}
+		return nil
+	}
+}
+
+// ValidateAuthorizedParty returns a validator which checks the "azp" claim. It must be
+// present if the token has multiple audiences and must name the client if present.
+func ValidateAuthorizedParty(clientID string) Validator {
+	return func(token *JwtToken) error {
+		azp, ok := token.Claims.Raw["azp"]
+		if !ok {
+			if len(token.Claims.Audiences()) > 1 {
+				return &MissingClaimError{"azp"}
+			}
+			return nil
+		}
+		if azp != clientID {
+			return ErrInvalidAuthorizedParty
+		}
+		return nil
+	}
+}
+
+// ValidateNonce returns a validator which checks that the "nonce" claim matches the nonce
+// sent in the authentication request.
+func ValidateNonce(nonce string) Validator {
+	return func(token *JwtToken) error {
+		value, ok := token.Claims.Raw["nonce"]
+		if !ok {
+			return &MissingClaimError{"nonce"}
+		}
+		str, _ := value.(string)
+		if subtle.ConstantTimeCompare([]byte(str), []byte(nonce)) != 1 {
+			return ErrInvalidNonce
+		}
+		return nil
+	}
+}
+
+// ValidateMaxAge returns a validator which checks that the "auth_time" claim is present and
+// not older than maxAge, the max_age parameter of the authentication request.
+func ValidateMaxAge(maxAge time.Duration) Validator {
+	return func(token *JwtToken) error {
+		if _, ok := token.Claims.Raw["auth_time"]; !ok {
+			return &MissingClaimError{"auth_time"}
+		}
+		authTime, err := intMember(token.Claims.Raw, "auth_time")
+		if err != nil {
+			return err
+		}
+		if time.Now().After(time.Unix(authTime, 0).Add(maxAge)) {
+			return ErrAuthTimeTooOld
+		}
+		return nil
+	}
+}
+
+// ValidateAccessTokenHash returns a validator which checks that the "at_hash" claim is
+// present and matches the access token issued with the ID token.
+func ValidateAccessTokenHash(accessToken string) Validator {
+	return validateTokenHash("at_hash", accessToken)
+}
+
+// ValidateCodeHash returns a validator which checks that the "c_hash" claim is present and
+// matches the authorization code issued with the ID token.
+func ValidateCodeHash(code string) Validator {
+	return validateTokenHash("c_hash", code)
+}
+
+func validateTokenHash(claim, value string) Validator {
+	return func(token *JwtToken) error {
+		hash, ok := token.Claims.Raw[claim].(string)
+		if !ok {
+			return &MissingClaimError{claim}
+		}
+		expected, err := TokenHash(value, token.Header.Alg)
+		if err != nil {
+			return err
+		}
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(expected)) != 1 {
+			return ErrInvalidTokenHash
+		}
+		return nil
+	}
+}
+
+// TokenHash computes the "at_hash" or "c_hash" value of an access token or authorization
+// code for an ID token signed with the algorithm: the base64url encoded left-most half of
+// the hash of the value (OpenID Connect Core 1.0 section 3.1.3.6).
+func TokenHash(value, algorithm string) (string, error) {
+	hash, ok := signingHashes[algorithm]
+	if algorithm == JWT_EdDSA {
+		// EdDSA with Ed25519 uses SHA-512
+		hash, ok = crypto.SHA512, true
+	}
+	if !ok {
+		return "", ErrUnknownAlgorithm
+	}
+	sum := digest(hash, []byte(value))
+	return base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2]), nil
+}
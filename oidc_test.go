@@ -0,0 +1,191 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+const (
+	oidcIssuer   = "https://accounts.example.com"
+	oidcClientID = "client"
+)
+
+func idToken(t *testing.T, alg Algorithm, modify func(claims *Claims)) string {
+	claims := &Claims{
+		Issuer:   oidcIssuer,
+		Subject:  "user",
+		Audience: oidcClientID,
+		Expires:  time.Now().Add(time.Hour).Unix(),
+		Raw: map[string]interface{}{
+			"nonce":     "n-0S6_WzA2Mj",
+			"auth_time": time.Now().Add(-time.Minute).Unix(),
+		},
+	}
+	if modify != nil {
+		modify(claims)
+	}
+	token, err := Create(claims, alg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestIDTokenVerifier(t *testing.T) {
+	rs256, _ := NewRS256(mustReadFixture("rsa"))
+	verifier := NewIDTokenVerifier(oidcIssuer, oidcClientID, rs256)
+	token, err := verifier.Verify(idToken(t, rs256, nil), ValidateNonce("n-0S6_WzA2Mj"), ValidateMaxAge(time.Hour))
+	if err != nil || token.Claims.Subject != "user" {
+		t.Log(err)
+		t.Fail()
+	}
+	for _, test := range []struct {
+		modify     func(claims *Claims)
+		validators []Validator
+		expected   error
+	}{
+		{func(c *Claims) { c.Subject = "" }, nil, &MissingClaimError{"sub"}},
+		{func(c *Claims) { c.Issuer = "https://other.example.com" }, nil, nil},
+		{func(c *Claims) { c.Audience = "other" }, nil, nil},
+		{func(c *Claims) { c.Expires = 0 }, nil, ErrTokenExpired},
+		{nil, []Validator{ValidateNonce("other")}, ErrInvalidNonce},
+		{func(c *Claims) { delete(c.Raw, "nonce") }, []Validator{ValidateNonce("n-0S6_WzA2Mj")}, &MissingClaimError{"nonce"}},
+		{nil, []Validator{ValidateMaxAge(time.Second)}, ErrAuthTimeTooOld},
+		{func(c *Claims) { delete(c.Raw, "auth_time") }, []Validator{ValidateMaxAge(time.Hour)}, &MissingClaimError{"auth_time"}},
+	} {
+		_, err := verifier.Verify(idToken(t, rs256, test.modify), test.validators...)
+		var missing *MissingClaimError
+		switch {
+		case err == nil:
+			t.Log("accepted")
+			t.Fail()
+		case errors.As(test.expected, &missing):
+			if actual, ok := err.(*MissingClaimError); !ok || actual.Claim != missing.Claim {
+				t.Log(err)
+				t.Fail()
+			}
+		case test.expected != nil && err != test.expected:
+			t.Log(err)
+			t.Fail()
+		}
+	}
+}
+
+func TestIDTokenAuthorizedParty(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	verifier := NewIDTokenVerifier(oidcIssuer, oidcClientID, hs256)
+	multiple := func(c *Claims) {
+		c.Audience = ""
+		c.Raw["aud"] = []string{oidcClientID, "other"}
+	}
+	_, err := verifier.Verify(idToken(t, hs256, multiple))
+	if missing, ok := err.(*MissingClaimError); !ok || missing.Claim != "azp" {
+		t.Log(err)
+		t.Fail()
+	}
+	token, err := verifier.Verify(idToken(t, hs256, func(c *Claims) {
+		multiple(c)
+		c.Raw["azp"] = oidcClientID
+	}))
+	if err != nil || len(token.Claims.Audiences()) != 2 {
+		t.Log(err)
+		t.Fail()
+	}
+	_, err = verifier.Verify(idToken(t, hs256, func(c *Claims) { c.Raw["azp"] = "other" }))
+	if err != ErrInvalidAuthorizedParty {
+		t.Log(err)
+		t.Fail()
+	}
+	_, err = verifier.Verify(idToken(t, hs256, func(c *Claims) {
+		c.Audience = ""
+		c.Raw["aud"] = []string{"other", "third"}
+		c.Raw["azp"] = oidcClientID
+	}))
+	if err == nil || err.Error() != "Invalid audience" {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestIDTokenKeySet(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	key, _ := NewKey("k1", hs256)
+	verifier := &IDTokenVerifier{Issuer: oidcIssuer, ClientID: oidcClientID, KeySet: NewKeySet(key)}
+	if _, err := verifier.Verify(idToken(t, key, nil)); err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+	if _, err := (&IDTokenVerifier{Issuer: oidcIssuer, Algorithm: hs256}).Verify(idToken(t, key, nil)); err == nil {
+		t.Fail()
+	}
+}
+
+func TestTokenHash(t *testing.T) {
+	// OpenID Connect Core 1.0 appendix A.4
+	hash, err := TokenHash("Qcb0Orv1zh30vL1MPRsbm-diHiMwcLyZvn1arpZv-Jxf_11jnpEX3Tgfvk", JWT_RS256)
+	if err != nil || hash != "LDktKdoQak3Pk0cnXxCltA" {
+		t.Log(hash, err)
+		t.Fail()
+	}
+	for alg, length := range map[string]int{JWT_HS256: 22, JWT_PS348: 32, JWT_ES512: 43, JWT_EdDSA: 43} {
+		hash, err := TokenHash("value", alg)
+		if err != nil || len(hash) != length {
+			t.Log(alg, hash, err)
+			t.Fail()
+		}
+	}
+	if _, err := TokenHash("value", JWT_NONE); err != ErrUnknownAlgorithm {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestIDTokenHashes(t *testing.T) {
+	for _, c := range benchmarkCases(t) {
+		atHash, _ := TokenHash("access-token", c.name)
+		cHash, _ := TokenHash("code", c.name)
+		raw := idToken(t, c.algorithm, func(claims *Claims) {
+			claims.Raw["at_hash"] = atHash
+			claims.Raw["c_hash"] = cHash
+		})
+		verifier := NewIDTokenVerifier(oidcIssuer, oidcClientID, c.algorithm)
+		if _, err := verifier.Verify(raw, ValidateAccessTokenHash("access-token"), ValidateCodeHash("code")); err != nil {
+			t.Log(c.name, err)
+			t.Fail()
+		}
+		if _, err := verifier.Verify(raw, ValidateAccessTokenHash("other")); err != ErrInvalidTokenHash {
+			t.Log(c.name, err)
+			t.Fail()
+		}
+	}
+	hs256, _ := NewHS256(testSecret("secret"))
+	_, err := NewIDTokenVerifier(oidcIssuer, oidcClientID, hs256).Verify(idToken(t, hs256, nil), ValidateCodeHash("code"))
+	if missing, ok := err.(*MissingClaimError); !ok || missing.Claim != "c_hash" {
+		t.Log(err)
+		t.Fail()
+	}
+}
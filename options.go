@@ -0,0 +1,73 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+// Option configures Create and Parse.
+type Option func(*options)
+
+type options struct {
+	allowUnsecured bool
+	limits         Limits
+	critical       *CriticalHeaders
+	headers        map[string]interface{}
+	crit           []string
+	cache          *Cache
+	typ            string
+	thumbprintKid  bool
+}
+
+// verification holds the options which decide whether a token is accepted.
+type verification struct {
+	allowUnsecured bool
+	limits         Limits
+	critical       *CriticalHeaders
+}
+
+func (o *options) verification() verification {
+	return verification{o.allowUnsecured, o.limits, o.critical}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{limits: DefaultLimits}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// DangerouslyAllowUnsecured allows creating and parsing unsecured JWTs ("alg": "none",
+// RFC 7519 section 6) with the Unsecured algorithm. Unsecured JWTs are not integrity
+// protected, anybody can create them. Never use this option for tokens from untrusted sources.
+func DangerouslyAllowUnsecured() Option {
+	return func(o *options) {
+		o.allowUnsecured = true
+	}
+}
+
+// WithType sets the "typ" header of tokens created with Create. The default is "jwt".
+func WithType(typ string) Option {
+	return func(o *options) {
+		o.typ = typ
+	}
+}
@@ -0,0 +1,195 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import "strings"
+
+// Scopes returns the scopes granted by the token. Scopes are read from the space
+// delimited "scope" claim (RFC 8693 section 4.2) and the "scp" claim, which may
+// be a string or an array of strings.
+func (c *Claims) Scopes() []string {
+	scopes := []string{}
+	if scope, ok := c.Raw["scope"].(string); ok {
+		scopes = append(scopes, strings.Fields(scope)...)
+	}
+	switch scp := c.Raw["scp"].(type) {
+	case string:
+		scopes = append(scopes, strings.Fields(scp)...)
+	case []interface{}:
+		scopes = append(scopes, stringValues(scp)...)
+	}
+	return unique(scopes)
+}
+
+// Roles returns the roles granted by the token. Roles are read from the "roles" and
+// "groups" claims, which may be a string or an array of strings.
+func (c *Claims) Roles() []string {
+	roles := []string{}
+	for _, claim := range []string{"roles", "groups"} {
+		switch value := c.Raw[claim].(type) {
+		case string:
+			roles = append(roles, value)
+		case []interface{}:
+			roles = append(roles, stringValues(value)...)
+		}
+	}
+	return unique(roles)
+}
+
+// Requirement is an authorization requirement on the scopes and roles of a token.
+// It returns the scopes and roles which are missing. A requirement is met if both are empty.
+type Requirement func(scopes, roles []string) (missingScopes, missingRoles []string)
+
+// Scope returns a requirement which is met if the scope is granted.
+func Scope(scope string) Requirement {
+	return func(scopes, roles []string) ([]string, []string) {
+		if contains(scopes, scope) {
+			return nil, nil
+		}
+		return []string{scope}, nil
+	}
+}
+
+// Role returns a requirement which is met if the role is granted.
+func Role(role string) Requirement {
+	return func(scopes, roles []string) ([]string, []string) {
+		if contains(roles, role) {
+			return nil, nil
+		}
+		return nil, []string{role}
+	}
+}
+
+// AllOf returns a requirement which is met if all requirements are met.
+func AllOf(requirements ...Requirement) Requirement {
+	return func(scopes, roles []string) ([]string, []string) {
+		missingScopes, missingRoles := []string{}, []string{}
+		for _, requirement := range requirements {
+			s, r := requirement(scopes, roles)
+			missingScopes = append(missingScopes, s...)
+			missingRoles = append(missingRoles, r...)
+		}
+		return unique(missingScopes), unique(missingRoles)
+	}
+}
+
+// AnyOf returns a requirement which is met if at least one of the requirements is met.
+// If none is met, the missing scopes and roles of all alternatives are reported. Without
+// requirements it is never met and reports the empty scope as missing.
+func AnyOf(requirements ...Requirement) Requirement {
+	return func(scopes, roles []string) ([]string, []string) {
+		if len(requirements) == 0 {
+			return []string{""}, nil
+		}
+		missingScopes, missingRoles := []string{}, []string{}
+		for _, requirement := range requirements {
+			s, r := requirement(scopes, roles)
+			if len(s) == 0 && len(r) == 0 {
+				return nil, nil
+			}
+			missingScopes = append(missingScopes, s...)
+			missingRoles = append(missingRoles, r...)
+		}
+		return unique(missingScopes), unique(missingRoles)
+	}
+}
+
+// AllScopes returns a requirement which is met if all scopes are granted.
+func AllScopes(scopes ...string) Requirement {
+	return AllOf(scopeRequirements(scopes)...)
+}
+
+// AnyScope returns a requirement which is met if at least one of the scopes is granted.
+// Without scopes it is never met.
+func AnyScope(scopes ...string) Requirement {
+	return AnyOf(scopeRequirements(scopes)...)
+}
+
+// AllRoles returns a requirement which is met if all roles are granted.
+func AllRoles(roles ...string) Requirement {
+	return AllOf(roleRequirements(roles)...)
+}
+
+// AnyRole returns a requirement which is met if at least one of the roles is granted.
+// Without roles it is never met.
+func AnyRole(roles ...string) Requirement {
+	return AnyOf(roleRequirements(roles)...)
+}
+
+// Require returns a validator which checks the requirement against the scopes and roles
+// of a token. It fails with an InsufficientScopeError listing what was missing.
+func Require(requirement Requirement) Validator {
+	return func(token *JwtToken) error {
+		missingScopes, missingRoles := requirement(token.Claims.Scopes(), token.Claims.Roles())
+		if len(missingScopes) == 0 && len(missingRoles) == 0 {
+			return nil
+		}
+		return &InsufficientScopeError{Missing: missingScopes, MissingRoles: missingRoles}
+	}
+}
+
+func scopeRequirements(scopes []string) []Requirement {
+	requirements := make([]Requirement, len(scopes))
+	for i, scope := range scopes {
+		requirements[i] = Scope(scope)
+	}
+	return requirements
+}
+
+func roleRequirements(roles []string) []Requirement {
+	requirements := make([]Requirement, len(roles))
+	for i, role := range roles {
+		requirements[i] = Role(role)
+	}
+	return requirements
+}
+
+func stringValues(values []interface{}) []string {
+	strs := []string{}
+	for _, value := range values {
+		if str, ok := value.(string); ok {
+			strs = append(strs, str)
+		}
+	}
+	return strs
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func unique(values []string) []string {
+	result := []string{}
+	for _, value := range values {
+		if !contains(result, value) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,142 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func tokenWithClaims(raw map[string]interface{}) *JwtToken {
+	return &JwtToken{Claims: Claims{Expires: time.Now().Add(time.Hour).Unix(), Raw: raw}}
+}
+
+func TestScopes(t *testing.T) {
+	token := tokenWithClaims(map[string]interface{}{
+		"scope": "read write",
+		"scp":   []interface{}{"write", "admin", 1},
+	})
+	scopes := token.Claims.Scopes()
+	if !reflect.DeepEqual(scopes, []string{"read", "write", "admin"}) {
+		t.Log(scopes)
+		t.Fail()
+	}
+	token = tokenWithClaims(map[string]interface{}{"scp": "read"})
+	scopes = token.Claims.Scopes()
+	if !reflect.DeepEqual(scopes, []string{"read"}) {
+		t.Log(scopes)
+		t.Fail()
+	}
+}
+
+func TestRoles(t *testing.T) {
+	token := tokenWithClaims(map[string]interface{}{
+		"roles":  []interface{}{"admin", "user"},
+		"groups": "staff",
+	})
+	roles := token.Claims.Roles()
+	if !reflect.DeepEqual(roles, []string{"admin", "user", "staff"}) {
+		t.Log(roles)
+		t.Fail()
+	}
+	if len(tokenWithClaims(nil).Claims.Roles()) != 0 {
+		t.Fail()
+	}
+}
+
+func TestRequireAllOf(t *testing.T) {
+	token := tokenWithClaims(map[string]interface{}{"scope": "read", "roles": []interface{}{"user"}})
+	err := token.Validate(Require(AllOf(AllScopes("read"), AllRoles("user"))))
+	if err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+	err = token.Validate(Require(AllOf(AllScopes("read", "write", "delete"), AllRoles("user", "admin"))))
+	var scopeErr *InsufficientScopeError
+	if !errors.As(err, &scopeErr) {
+		t.Log(err)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(scopeErr.Missing, []string{"write", "delete"}) || !reflect.DeepEqual(scopeErr.MissingRoles, []string{"admin"}) {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestRequireAnyOf(t *testing.T) {
+	token := tokenWithClaims(map[string]interface{}{"scope": "read", "roles": []interface{}{"user"}})
+	err := token.Validate(Require(AnyOf(AnyScope("write", "read"), AnyRole("admin"))))
+	if err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+	err = token.Validate(Require(AnyOf(AllScopes("write", "read"), AnyRole("admin"))))
+	var scopeErr *InsufficientScopeError
+	if !errors.As(err, &scopeErr) {
+		t.Log(err)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(scopeErr.Missing, []string{"write"}) || !reflect.DeepEqual(scopeErr.MissingRoles, []string{"admin"}) {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestRequireAnyOfEmpty(t *testing.T) {
+	token := tokenWithClaims(map[string]interface{}{"scope": "read", "roles": []interface{}{"user"}})
+	for _, requirement := range []Requirement{AnyOf(), AnyScope(), AnyRole(), AllOf(AllScopes("read"), AnyScope())} {
+		err := token.Validate(Require(requirement))
+		var scopeErr *InsufficientScopeError
+		if !errors.As(err, &scopeErr) {
+			t.Log(err)
+			t.Fail()
+		}
+	}
+}
+
+func TestInsufficientScopeError(t *testing.T) {
+	err := &InsufficientScopeError{Missing: []string{"read"}, MissingRoles: []string{"admin"}}
+	if err.Error() != "Insufficient scope. Missing scopes: read. Missing roles: admin." {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestMiddlewareRequire(t *testing.T) {
+	alg, _ := NewHS256(testSecret("secret"))
+	raw, _ := Create(&Claims{Expires: time.Now().Add(time.Hour).Unix()}, alg)
+	m := NewMiddleware(alg, Require(AllScopes("read", "write")))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+raw)
+	w, _ := serve(m, r)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Header().Get("WWW-Authenticate"), `scope="read write"`) {
+		t.Log(w.Header().Get("WWW-Authenticate"))
+		t.Fail()
+	}
+}
@@ -0,0 +1,638 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"crypto"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// TYP_KB_JWT is the "typ" header of key binding JWTs.
+	TYP_KB_JWT                = "kb+jwt"
+	SD_JWT_KB_DEFAULT_MAX_AGE = 5 * time.Minute
+	sdDigestsName             = "_sd"
+	sdAlgorithmName           = "_sd_alg"
+	sdArrayElementName        = "..."
+	sdSeparator               = "~"
+)
+
+var (
+	ErrInvalidDisclosure  = errors.New("Invalid disclosure")
+	ErrInvalidKeyBinding  = errors.New("Invalid key binding JWT")
+	ErrKeyBindingRequired = errors.New("SD-JWT requires a key binding JWT")
+)
+
+// Disclosable marks a claim value or an array element as selectively disclosable. Values are
+// processed recursively, so disclosable values may contain disclosable values themselves.
+type Disclosable struct {
+	Value interface{}
+}
+
+// SD marks a value as selectively disclosable. It may be used as value of an object member or
+// as array element in the Raw claims passed to SDJWTIssuer.Issue. Nested objects and arrays
+// must be map[string]interface{} and []interface{}.
+func SD(value interface{}) Disclosable {
+	return Disclosable{value}
+}
+
+// Disclosure discloses a claim or an array element of an SD-JWT.
+type Disclosure struct {
+	Salt string
+	// Name is the claim name. It is empty for array elements.
+	Name  string
+	Value interface{}
+	// Encoded is the base64url encoded disclosure. Digests are computed from it, so it is
+	// passed on unchanged.
+	Encoded string
+
+	element bool
+}
+
+func newDisclosure(name string, value interface{}, element bool) (*Disclosure, error) {
+	if !element && (name == sdDigestsName || name == sdArrayElementName) {
+		return nil, errors.New("Claim name can't be disclosed selectively: " + name)
+	}
+	salt, err := newTokenID()
+	if err != nil {
+		return nil, err
+	}
+	array := []interface{}{salt, name, value}
+	if element {
+		array = []interface{}{salt, value}
+	}
+	data, err := json.Marshal(array)
+	if err != nil {
+		return nil, err
+	}
+	return &Disclosure{
+		Salt:    salt,
+		Name:    name,
+		Value:   value,
+		Encoded: base64.RawURLEncoding.EncodeToString(data),
+		element: element,
+	}, nil
+}
+
+// ParseDisclosure decodes a base64url encoded disclosure.
+func ParseDisclosure(encoded string) (*Disclosure, error) {
+	data, err := base64.RawURLEncoding.Strict().DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidDisclosure
+	}
+	array, err := parseArray(data, DefaultLimits.MaxDepth)
+	if err != nil || len(array) < 2 || len(array) > 3 {
+		return nil, ErrInvalidDisclosure
+	}
+	disclosure := &Disclosure{Encoded: encoded, Value: array[len(array)-1], element: len(array) == 2}
+	var ok bool
+	if disclosure.Salt, ok = array[0].(string); !ok {
+		return nil, ErrInvalidDisclosure
+	}
+	if !disclosure.element {
+		disclosure.Name, ok = array[1].(string)
+		if !ok || disclosure.Name == sdDigestsName || disclosure.Name == sdArrayElementName {
+			return nil, ErrInvalidDisclosure
+		}
+	}
+	return disclosure, nil
+}
+
+// IsArrayElement reports whether the disclosure discloses an array element.
+func (d *Disclosure) IsArrayElement() bool {
+	return d.element
+}
+
+// Digest returns the base64url encoded hash of the encoded disclosure.
+func (d *Disclosure) Digest(hash crypto.Hash) string {
+	return sdDigest(d.Encoded, hash)
+}
+
+func sdDigest(value string, hash crypto.Hash) string {
+	return base64.RawURLEncoding.EncodeToString(digest(hash, []byte(value)))
+}
+
+// sdHash returns the hash function named by the "_sd_alg" claim. SHA-256 is the default.
+func sdHash(claims map[string]interface{}) (crypto.Hash, error) {
+	name, err := stringMember(claims, sdAlgorithmName)
+	if err != nil {
+		return 0, err
+	}
+	if name == "" {
+		return crypto.SHA256, nil
+	}
+	for hash, hashName := range thumbprintHashes {
+		if hashName == name {
+			return hash, nil
+		}
+	}
+	return 0, errors.New("Unsupported _sd_alg: " + name)
+}
+
+// SDJWT is a selective disclosure JWT (RFC 9901) in its parts: the issuer-signed JWT, the
+// disclosures and an optional key binding JWT.
+type SDJWT struct {
+	JWT         string
+	Disclosures []*Disclosure
+	KeyBinding  string
+}
+
+// ParseSDJWT splits an SD-JWT in the compact serialization
+// "<JWT>~<Disclosure 1>~...~<Disclosure N>~<KB-JWT>" into its parts. Signatures are not
+// verified, use SDJWTVerifier for that.
+func ParseSDJWT(raw string) (*SDJWT, error) {
+	parts := strings.Split(raw, sdSeparator)
+	if len(parts) < 2 || parts[0] == "" {
+		return nil, ErrInvalidTokenFormat
+	}
+	sd := &SDJWT{JWT: parts[0], KeyBinding: parts[len(parts)-1]}
+	for _, encoded := range parts[1 : len(parts)-1] {
+		disclosure, err := ParseDisclosure(encoded)
+		if err != nil {
+			return nil, err
+		}
+		sd.Disclosures = append(sd.Disclosures, disclosure)
+	}
+	return sd, nil
+}
+
+// String returns the SD-JWT in the compact serialization.
+func (s *SDJWT) String() string {
+	return s.presentation() + s.KeyBinding
+}
+
+// presentation returns the serialization without key binding JWT, which is the input of "sd_hash".
+func (s *SDJWT) presentation() string {
+	var b strings.Builder
+	b.WriteString(s.JWT)
+	b.WriteString(sdSeparator)
+	for _, disclosure := range s.Disclosures {
+		b.WriteString(disclosure.Encoded)
+		b.WriteString(sdSeparator)
+	}
+	return b.String()
+}
+
+// Present returns a presentation with the disclosures for which keep returns true. The
+// disclosures of the objects and arrays containing a kept disclosure are kept as well, as
+// the claim can't be disclosed without them. The key binding JWT is removed.
+func (s *SDJWT) Present(keep func(disclosure *Disclosure) bool) (*SDJWT, error) {
+	unverified, err := Decode(s.JWT)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := sdHash(unverified.Claims.Raw)
+	if err != nil {
+		return nil, err
+	}
+	parents := map[string]*Disclosure{}
+	for _, disclosure := range s.Disclosures {
+		for _, digest := range sdDigests(disclosure.Value, nil) {
+			parents[digest] = disclosure
+		}
+	}
+	kept := map[*Disclosure]bool{}
+	for _, disclosure := range s.Disclosures {
+		if !keep(disclosure) {
+			continue
+		}
+		for d := disclosure; d != nil && !kept[d]; d = parents[d.Digest(hash)] {
+			kept[d] = true
+		}
+	}
+	presentation := &SDJWT{JWT: s.JWT}
+	for _, disclosure := range s.Disclosures {
+		if kept[disclosure] {
+			presentation.Disclosures = append(presentation.Disclosures, disclosure)
+		}
+	}
+	return presentation, nil
+}
+
+// sdDigests appends the digests referenced in a value to digests.
+func sdDigests(value interface{}, digests []string) []string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, member := range v {
+			if list, ok := member.([]interface{}); ok && name == sdDigestsName {
+				for _, item := range list {
+					if digest, ok := item.(string); ok {
+						digests = append(digests, digest)
+					}
+				}
+				continue
+			}
+			if digest, ok := member.(string); ok && name == sdArrayElementName && len(v) == 1 {
+				digests = append(digests, digest)
+				continue
+			}
+			digests = sdDigests(member, digests)
+		}
+	case []interface{}:
+		for _, element := range v {
+			digests = sdDigests(element, digests)
+		}
+	}
+	return digests
+}
+
+// Bind returns a copy of the SD-JWT with a key binding JWT signed with the holder key. The
+// algorithm must use the key of the "cnf" claim of the issuer-signed JWT.
+func (s *SDJWT) Bind(algorithm Algorithm, audience, nonce string) (*SDJWT, error) {
+	unverified, err := Decode(s.JWT)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := sdHash(unverified.Claims.Raw)
+	if err != nil {
+		return nil, err
+	}
+	bound := &SDJWT{JWT: s.JWT, Disclosures: s.Disclosures}
+	claims := &Claims{Audience: audience, Raw: map[string]interface{}{
+		"nonce":   nonce,
+		"sd_hash": sdDigest(bound.presentation(), hash),
+	}}
+	if bound.KeyBinding, err = Create(claims, algorithm, WithType(TYP_KB_JWT)); err != nil {
+		return nil, err
+	}
+	return bound, nil
+}
+
+// SDJWTIssuer issues SD-JWTs.
+type SDJWTIssuer struct {
+	// Algorithm signs the issuer-signed JWT.
+	Algorithm Algorithm
+	// Hash computes the digests of the disclosures. SHA-256 is used if zero.
+	Hash crypto.Hash
+	// Decoys is the number of decoy digests added to every "_sd" array, so the number of
+	// selectively disclosable claims is hidden.
+	Decoys int
+	// HolderKey is the public key of the holder. It is added as "cnf" claim and enables key
+	// binding if not nil.
+	HolderKey *JWK
+	// Options are applied when creating the issuer-signed JWT, e.g. WithType("dc+sd-jwt").
+	Options []Option
+}
+
+// NewSDJWTIssuer creates a new SD-JWT issuer.
+func NewSDJWTIssuer(algorithm Algorithm) *SDJWTIssuer {
+	return &SDJWTIssuer{Algorithm: algorithm}
+}
+
+// Issue creates an SD-JWT. Claims in Raw marked with SD are replaced by digests and
+// returned as disclosures.
+func (i *SDJWTIssuer) Issue(claims *Claims) (*SDJWT, error) {
+	if claims == nil {
+		claims = &Claims{}
+	}
+	hash := i.Hash
+	if hash == 0 {
+		hash = crypto.SHA256
+	}
+	hashName, ok := thumbprintHashes[hash]
+	if !ok {
+		return nil, errors.New("Unsupported hash function")
+	}
+	var disclosures []*Disclosure
+	raw, err := i.conceal(claims.Raw, hash, &disclosures)
+	if err != nil {
+		return nil, err
+	}
+	concealed := *claims
+	concealed.Raw = raw.(map[string]interface{})
+	concealed.Raw[sdAlgorithmName] = hashName
+	if i.HolderKey != nil {
+		public := i.HolderKey.Public()
+		if public == nil {
+			return nil, errors.New("Holder key must be an asymmetric key")
+		}
+		concealed.SetConfirmation(&Confirmation{JWK: public})
+	}
+	token, err := Create(&concealed, i.Algorithm, i.Options...)
+	if err != nil {
+		return nil, err
+	}
+	return &SDJWT{JWT: token, Disclosures: disclosures}, nil
+}
+
+// conceal replaces the disclosable values by digests and collects their disclosures.
+func (i *SDJWTIssuer) conceal(value interface{}, hash crypto.Hash, disclosures *[]*Disclosure) (interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		return map[string]interface{}{}, nil
+	case map[string]interface{}:
+		concealed := make(map[string]interface{}, len(v))
+		var digests []string
+		for name, member := range v {
+			disclosable, ok := member.(Disclosable)
+			if !ok {
+				inner, err := i.conceal(member, hash, disclosures)
+				if err != nil {
+					return nil, err
+				}
+				concealed[name] = inner
+				continue
+			}
+			inner, err := i.conceal(disclosable.Value, hash, disclosures)
+			if err != nil {
+				return nil, err
+			}
+			disclosure, err := newDisclosure(name, inner, false)
+			if err != nil {
+				return nil, err
+			}
+			*disclosures = append(*disclosures, disclosure)
+			digests = append(digests, disclosure.Digest(hash))
+		}
+		if len(digests) > 0 {
+			for n := 0; n < i.Decoys; n++ {
+				decoy, err := newTokenID()
+				if err != nil {
+					return nil, err
+				}
+				digests = append(digests, sdDigest(decoy, hash))
+			}
+			// sorting hides the order of the claims
+			sort.Strings(digests)
+			list := make([]interface{}, len(digests))
+			for n, digest := range digests {
+				list[n] = digest
+			}
+			concealed[sdDigestsName] = list
+		}
+		return concealed, nil
+	case []interface{}:
+		concealed := make([]interface{}, len(v))
+		for n, element := range v {
+			disclosable, ok := element.(Disclosable)
+			if !ok {
+				inner, err := i.conceal(element, hash, disclosures)
+				if err != nil {
+					return nil, err
+				}
+				concealed[n] = inner
+				continue
+			}
+			inner, err := i.conceal(disclosable.Value, hash, disclosures)
+			if err != nil {
+				return nil, err
+			}
+			disclosure, err := newDisclosure("", inner, true)
+			if err != nil {
+				return nil, err
+			}
+			*disclosures = append(*disclosures, disclosure)
+			concealed[n] = map[string]interface{}{sdArrayElementName: disclosure.Digest(hash)}
+		}
+		return concealed, nil
+	case Disclosable:
+		return nil, errors.New("Disclosable values must be object members or array elements")
+	}
+	return value, nil
+}
+
+// SDJWTVerifier verifies SD-JWT presentations. The verified token holds the disclosed claims
+// in place of their digests.
+type SDJWTVerifier struct {
+	// Algorithm verifies the signature of the issuer-signed JWT.
+	Algorithm Algorithm
+	// KeySet verifies the signature of the issuer-signed JWT with the key matching the "kid"
+	// header. It takes precedence over Algorithm.
+	KeySet *KeySet
+	// RequireKeyBinding rejects presentations without key binding JWT.
+	RequireKeyBinding bool
+	// Audience is the expected "aud" claim of the key binding JWT. It is required if a key
+	// binding JWT is present.
+	Audience string
+	// Nonce is the expected "nonce" claim of the key binding JWT. It is checked if not empty.
+	Nonce string
+	// MaxAge is the maximum age of the key binding JWT. SD_JWT_KB_DEFAULT_MAX_AGE is used if zero.
+	MaxAge time.Duration
+	// Leeway accepts key binding JWTs issued slightly in the future because of clock skew.
+	Leeway time.Duration
+	// Options are applied when parsing the issuer-signed JWT.
+	Options []Option
+
+	now func() time.Time
+}
+
+// NewSDJWTVerifier creates a new SD-JWT verifier.
+func NewSDJWTVerifier(algorithm Algorithm) *SDJWTVerifier {
+	return &SDJWTVerifier{Algorithm: algorithm}
+}
+
+// Verify verifies an SD-JWT presentation and returns the token with the disclosed claims. The
+// validators are run on the disclosed claims.
+func (v *SDJWTVerifier) Verify(raw string, validators ...Validator) (*JwtToken, error) {
+	sd, err := ParseSDJWT(raw)
+	if err != nil {
+		return nil, err
+	}
+	token, err := parseAndValidate(sd.JWT, v.Algorithm, v.KeySet, nil, v.Options)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := sdHash(token.Claims.Raw)
+	if err != nil {
+		return nil, err
+	}
+	digests := map[string]*Disclosure{}
+	for _, disclosure := range sd.Disclosures {
+		digest := disclosure.Digest(hash)
+		if _, ok := digests[digest]; ok {
+			return nil, errors.New("Disclosure is included more than once")
+		}
+		digests[digest] = disclosure
+	}
+	seen := map[string]bool{}
+	revealed, err := reveal(token.Claims.Raw, digests, seen)
+	if err != nil {
+		return nil, err
+	}
+	for digest := range digests {
+		if !seen[digest] {
+			return nil, errors.New("Disclosure is not referenced by the SD-JWT")
+		}
+	}
+	payload := revealed.(map[string]interface{})
+	delete(payload, sdAlgorithmName)
+	claims, err := claimsFromMap(payload)
+	if err != nil {
+		return nil, err
+	}
+	disclosed := &JwtToken{Header: token.Header, Claims: claims, signature: token.signature}
+	if sd.KeyBinding != "" || v.RequireKeyBinding {
+		if err := v.verifyKeyBinding(sd, disclosed, hash); err != nil {
+			return nil, err
+		}
+	}
+	if err := disclosed.Validate(validators...); err != nil {
+		return nil, err
+	}
+	return disclosed, nil
+}
+
+// reveal replaces the digests of a value by the disclosed values. Digests without disclosure
+// are removed. All digests are recorded in seen, a digest must not occur more than once.
+func reveal(value interface{}, digests map[string]*Disclosure, seen map[string]bool) (interface{}, error) {
+	lookup := func(item interface{}) (*Disclosure, error) {
+		digest, ok := item.(string)
+		if !ok || seen[digest] {
+			return nil, ErrInvalidDisclosure
+		}
+		seen[digest] = true
+		return digests[digest], nil
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		revealed := make(map[string]interface{}, len(v))
+		for name, member := range v {
+			if name == sdDigestsName {
+				continue
+			}
+			inner, err := reveal(member, digests, seen)
+			if err != nil {
+				return nil, err
+			}
+			revealed[name] = inner
+		}
+		list, ok := v[sdDigestsName]
+		if !ok {
+			return revealed, nil
+		}
+		items, ok := list.([]interface{})
+		if !ok {
+			return nil, ErrInvalidDisclosure
+		}
+		for _, item := range items {
+			disclosure, err := lookup(item)
+			if err != nil {
+				return nil, err
+			}
+			if disclosure == nil {
+				continue
+			}
+			if _, exists := revealed[disclosure.Name]; exists || disclosure.element {
+				return nil, ErrInvalidDisclosure
+			}
+			inner, err := reveal(disclosure.Value, digests, seen)
+			if err != nil {
+				return nil, err
+			}
+			revealed[disclosure.Name] = inner
+		}
+		return revealed, nil
+	case []interface{}:
+		revealed := make([]interface{}, 0, len(v))
+		for _, element := range v {
+			if object, ok := element.(map[string]interface{}); ok && len(object) == 1 {
+				if item, ok := object[sdArrayElementName]; ok {
+					disclosure, err := lookup(item)
+					if err != nil {
+						return nil, err
+					}
+					if disclosure == nil {
+						continue
+					}
+					if !disclosure.element {
+						return nil, ErrInvalidDisclosure
+					}
+					element = disclosure.Value
+				}
+			}
+			inner, err := reveal(element, digests, seen)
+			if err != nil {
+				return nil, err
+			}
+			revealed = append(revealed, inner)
+		}
+		return revealed, nil
+	}
+	return value, nil
+}
+
+// verifyKeyBinding verifies the key binding JWT with the key of the "cnf" claim.
+func (v *SDJWTVerifier) verifyKeyBinding(sd *SDJWT, token *JwtToken, hash crypto.Hash) error {
+	if sd.KeyBinding == "" {
+		return ErrKeyBindingRequired
+	}
+	if v.Audience == "" {
+		return errors.New("Audience can't be empty")
+	}
+	cnf, err := token.Claims.Confirmation()
+	if err != nil {
+		return err
+	}
+	if cnf == nil || cnf.JWK == nil {
+		return ErrInvalidKeyBinding
+	}
+	unverified, err := Decode(sd.KeyBinding, v.Options...)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(unverified.Header.Typ, TYP_KB_JWT) {
+		return ErrInvalidKeyBinding
+	}
+	if err := checkAlgorithm(unverified.Header.Alg); err != nil {
+		return err
+	}
+	alg, err := cnf.JWK.Algorithm(unverified.Header.Alg)
+	if err != nil {
+		return ErrInvalidKeyBinding
+	}
+	binding, err := unverified.Verify(alg, v.Options...)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if v.now != nil {
+		now = v.now()
+	}
+	maxAge := v.MaxAge
+	if maxAge == 0 {
+		maxAge = SD_JWT_KB_DEFAULT_MAX_AGE
+	}
+	issued := time.Unix(binding.Claims.IssuedAt, 0)
+	if binding.Claims.IssuedAt == 0 || issued.After(now.Add(v.Leeway)) || issued.Before(now.Add(-maxAge-v.Leeway)) {
+		return ErrInvalidKeyBinding
+	}
+	if !contains(binding.Claims.Audiences(), v.Audience) {
+		return ErrInvalidKeyBinding
+	}
+	nonce, _ := binding.Claims.Raw["nonce"].(string)
+	if nonce == "" || (v.Nonce != "" && subtle.ConstantTimeCompare([]byte(nonce), []byte(v.Nonce)) != 1) {
+		return ErrInvalidKeyBinding
+	}
+	sdHashValue, _ := binding.Claims.Raw["sd_hash"].(string)
+	if subtle.ConstantTimeCompare([]byte(sdHashValue), []byte(sdDigest(sd.presentation(), hash))) != 1 {
+		return ErrInvalidKeyBinding
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"crypto"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+func issueSDJWT(t *testing.T) (*SDJWT, *GeneratedKey, Algorithm) {
+	es256, _ := NewES256(mustReadFixture("ecdsa_256"))
+	holder, _ := GenerateKey(JWT_ES256)
+	issuer := NewSDJWTIssuer(es256)
+	issuer.HolderKey = holder.PrivateJWK
+	issuer.Decoys = 2
+	sd, err := issuer.Issue(&Claims{
+		Issuer:  "https://issuer.example.com",
+		Expires: time.Now().Add(time.Hour).Unix(),
+		Raw: map[string]interface{}{
+			"given_name": SD("John"),
+			"email":      "john@example.com",
+			"address": SD(map[string]interface{}{
+				"street":  SD("Main Street"),
+				"country": "DE",
+			}),
+			"nationalities": []interface{}{SD("US"), "DE"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sd, holder, es256
+}
+
+func TestSDJWTDisclosure(t *testing.T) {
+	// RFC 9901 section 4.2.3
+	disclosure, err := ParseDisclosure("WyI2cU1RdlJMNWhhaiIsICJmYW1pbHlfbmFtZSIsICJNw7ZiaXVzIl0")
+	if err != nil || disclosure.Name != "family_name" || disclosure.Value != "Möbius" || disclosure.IsArrayElement() {
+		t.Log(disclosure, err)
+		t.Fail()
+	}
+	if digest := disclosure.Digest(crypto.SHA256); digest != "uutlBuYeMDyjLLTpf6Jxi7yNkEF35jdyWMn9U7b_RYY" {
+		t.Log(digest)
+		t.Fail()
+	}
+	for _, value := range []string{`["salt"]`, `["salt","_sd","x"]`, `[1,"name","x"]`, `["salt","a","b","c"]`, `{"a":1}`} {
+		if _, err := ParseDisclosure(base64.RawURLEncoding.EncodeToString([]byte(value))); err != ErrInvalidDisclosure {
+			t.Log(value, err)
+			t.Fail()
+		}
+	}
+}
+
+func TestSDJWT(t *testing.T) {
+	sd, _, es256 := issueSDJWT(t)
+	if len(sd.Disclosures) != 4 {
+		t.Fatal(len(sd.Disclosures))
+	}
+	unverified, _ := Decode(sd.JWT)
+	if _, ok := unverified.Claims.Raw["given_name"]; ok || len(unverified.Claims.Raw["_sd"].([]interface{})) != 4 {
+		t.Log(unverified.Claims.Raw)
+		t.Fail()
+	}
+	parsed, err := ParseSDJWT(sd.String())
+	if err != nil || parsed.String() != sd.String() {
+		t.Fatal(err)
+	}
+	token, err := NewSDJWTVerifier(es256).Verify(sd.String(), ValidateIssuer("https://issuer.example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := token.Claims.Raw
+	address, _ := raw["address"].(map[string]interface{})
+	nationalities, _ := raw["nationalities"].([]interface{})
+	if raw["given_name"] != "John" || address["street"] != "Main Street" || address["country"] != "DE" ||
+		len(nationalities) != 2 || nationalities[0] != "US" {
+		t.Log(raw)
+		t.Fail()
+	}
+	if _, ok := raw["_sd"]; ok {
+		t.Fail()
+	}
+	if _, ok := raw["_sd_alg"]; ok {
+		t.Fail()
+	}
+}
+
+func TestSDJWTPresent(t *testing.T) {
+	sd, _, es256 := issueSDJWT(t)
+	presentation, err := sd.Present(func(d *Disclosure) bool { return d.Name == "street" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(presentation.Disclosures) != 2 {
+		t.Log(len(presentation.Disclosures))
+		t.Fail()
+	}
+	token, err := NewSDJWTVerifier(es256).Verify(presentation.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := token.Claims.Raw
+	address, _ := raw["address"].(map[string]interface{})
+	nationalities, _ := raw["nationalities"].([]interface{})
+	if _, ok := raw["given_name"]; ok || address["street"] != "Main Street" || len(nationalities) != 1 || nationalities[0] != "DE" {
+		t.Log(raw)
+		t.Fail()
+	}
+	none, _ := sd.Present(func(d *Disclosure) bool { return false })
+	if none.String() != sd.JWT+"~" {
+		t.Log(none.String())
+		t.Fail()
+	}
+}
+
+func TestSDJWTKeyBinding(t *testing.T) {
+	sd, holder, es256 := issueSDJWT(t)
+	presentation, _ := sd.Present(func(d *Disclosure) bool { return d.Name == "given_name" })
+	bound, err := presentation.Bind(holder.Algorithm, "https://verifier.example.com", "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := NewSDJWTVerifier(es256)
+	verifier.RequireKeyBinding = true
+	verifier.Audience = "https://verifier.example.com"
+	verifier.Nonce = "nonce"
+	if _, err := verifier.Verify(bound.String()); err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+	if _, err := verifier.Verify(presentation.String()); err != ErrKeyBindingRequired {
+		t.Log(err)
+		t.Fail()
+	}
+	// disclosures added after binding change the sd_hash
+	tampered := &SDJWT{JWT: sd.JWT, Disclosures: sd.Disclosures, KeyBinding: bound.KeyBinding}
+	if _, err := verifier.Verify(tampered.String()); err != ErrInvalidKeyBinding {
+		t.Log(err)
+		t.Fail()
+	}
+	verifier.Nonce = "other"
+	if _, err := verifier.Verify(bound.String()); err != ErrInvalidKeyBinding {
+		t.Log(err)
+		t.Fail()
+	}
+	verifier.Nonce = "nonce"
+	verifier.now = func() time.Time { return time.Now().Add(time.Hour) }
+	if _, err := verifier.Verify(bound.String()); err != ErrInvalidKeyBinding {
+		t.Log(err)
+		t.Fail()
+	}
+	verifier.now = nil
+	other, _ := GenerateKey(JWT_ES256)
+	forged, _ := presentation.Bind(other.Algorithm, "https://verifier.example.com", "nonce")
+	if _, err := verifier.Verify(forged.String()); err != ErrInvalidSignature {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestSDJWTInvalidDisclosures(t *testing.T) {
+	sd, _, es256 := issueSDJWT(t)
+	other, _, _ := issueSDJWT(t)
+	verifier := NewSDJWTVerifier(es256)
+	for _, invalid := range []*SDJWT{
+		{JWT: sd.JWT, Disclosures: append([]*Disclosure{sd.Disclosures[0]}, sd.Disclosures...)},
+		{JWT: sd.JWT, Disclosures: append([]*Disclosure{other.Disclosures[0]}, sd.Disclosures...)},
+	} {
+		if _, err := verifier.Verify(invalid.String()); err == nil {
+			t.Fail()
+		}
+	}
+	if _, err := verifier.Verify(sd.JWT); err != ErrInvalidTokenFormat {
+		t.Log(err)
+		t.Fail()
+	}
+	if _, err := verifier.Verify(sd.JWT + "~~~"); err != ErrInvalidDisclosure {
+		t.Log(err)
+		t.Fail()
+	}
+	if _, err := NewSDJWTIssuer(es256).Issue(&Claims{Raw: map[string]interface{}{"_sd": SD("x")}}); err == nil {
+		t.Fail()
+	}
+	if strings.Count(sd.String(), "~") != len(sd.Disclosures)+1 {
+		t.Fail()
+	}
+}
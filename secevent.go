@@ -0,0 +1,353 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	// TYP_SECEVENT_JWT is the "typ" header of security event tokens (RFC 8417 section 2.3).
+	TYP_SECEVENT_JWT = "secevent+jwt"
+	// TYP_LOGOUT_JWT is the "typ" header of logout tokens (OpenID Connect Back-Channel Logout 1.0 section 2.4).
+	TYP_LOGOUT_JWT = "logout+jwt"
+	// BACKCHANNEL_LOGOUT_EVENT is the event type of logout tokens.
+	BACKCHANNEL_LOGOUT_EVENT = "http://schemas.openid.net/event/backchannel-logout"
+)
+
+var (
+	ErrEventNonce    = errors.New("Security event tokens must not contain a nonce")
+	ErrInvalidEvents = errors.New("Invalid events claim")
+)
+
+// SecurityEvent holds the claims of a security event token (RFC 8417 section 2.2). Claims are
+// read from and written to the embedded standard claims.
+type SecurityEvent struct {
+	Claims
+	// ID is the "jti" claim.
+	ID string
+	// Events is the "events" claim. It maps event type URIs to JSON objects with the event
+	// specific payload.
+	Events map[string]interface{}
+	// TransactionID is the "txn" claim.
+	TransactionID string
+	// TimeOfEvent is the "toe" claim.
+	TimeOfEvent int64
+}
+
+// CreateSecurityEvent creates a security event token with the "typ" header "secevent+jwt". A
+// random "jti" is generated if ID is empty. The "iss" claim and at least one event are required.
+func CreateSecurityEvent(event *SecurityEvent, algorithm Algorithm, opts ...Option) (string, error) {
+	return createSecurityEvent(event, TYP_SECEVENT_JWT, algorithm, opts)
+}
+
+func createSecurityEvent(event *SecurityEvent, typ string, algorithm Algorithm, opts []Option) (string, error) {
+	if event == nil {
+		return "", errors.New("Security event can't be nil")
+	}
+	if len(event.Events) == 0 {
+		return "", &MissingClaimError{"events"}
+	}
+	if _, ok := event.Raw["nonce"]; ok {
+		return "", ErrEventNonce
+	}
+	claims := event.claims()
+	if event.ID == "" {
+		id, err := newTokenID()
+		if err != nil {
+			return "", err
+		}
+		claims.Raw["jti"] = id
+	}
+	if err := requireClaims("iss")(&JwtToken{Claims: claims}); err != nil {
+		return "", err
+	}
+	return Create(&claims, algorithm, append(opts, WithType(typ))...)
+}
+
+// MarshalJSON encodes the security event claims together with the standard claims.
+func (e SecurityEvent) MarshalJSON() ([]byte, error) {
+	return e.claims().MarshalJSON()
+}
+
+// UnmarshalJSON decodes the standard claims and the security event claims.
+func (e *SecurityEvent) UnmarshalJSON(data []byte) error {
+	var claims Claims
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return err
+	}
+	event, err := newSecurityEvent(&claims)
+	if err != nil {
+		return err
+	}
+	*e = *event
+	return nil
+}
+
+// claims returns the standard claims with the security event claims added to a copy of Raw.
+func (e SecurityEvent) claims() Claims {
+	claims := e.Claims
+	claims.Raw = map[string]interface{}{}
+	for name, value := range e.Raw {
+		claims.Raw[name] = value
+	}
+	if e.ID != "" {
+		claims.Raw["jti"] = e.ID
+	}
+	if e.Events != nil {
+		claims.Raw["events"] = e.Events
+	}
+	if e.TransactionID != "" {
+		claims.Raw["txn"] = e.TransactionID
+	}
+	if e.TimeOfEvent != 0 {
+		claims.Raw["toe"] = e.TimeOfEvent
+	}
+	return claims
+}
+
+// SecurityEventVerifier verifies security event tokens. Tokens must have the "typ" header
+// "secevent+jwt", so other tokens can't be used as security events. Security events usually
+// don't expire, the "exp" claim is only checked if present.
+type SecurityEventVerifier struct {
+	// Issuer is the expected "iss" claim.
+	Issuer string
+	// Audience must be one of the audiences if not empty.
+	Audience string
+	// Algorithm verifies the token signature.
+	Algorithm Algorithm
+	// KeySet verifies the token signature with the key matching the "kid" header. It takes
+	// precedence over Algorithm.
+	KeySet *KeySet
+	// Options are applied when parsing the token.
+	Options []Option
+}
+
+// NewSecurityEventVerifier creates a new security event verifier.
+func NewSecurityEventVerifier(issuer, audience string, algorithm Algorithm) *SecurityEventVerifier {
+	return &SecurityEventVerifier{Issuer: issuer, Audience: audience, Algorithm: algorithm}
+}
+
+// Verify parses and validates a security event token. The validators are run after the
+// checks of the verifier.
+func (v *SecurityEventVerifier) Verify(raw string, validators ...Validator) (*SecurityEvent, error) {
+	if v.Issuer == "" {
+		return nil, errors.New("Issuer can't be empty")
+	}
+	checks := []Validator{
+		ValidateType(TYP_SECEVENT_JWT),
+		requireClaims("iss", "iat", "jti", "events"),
+		ValidateIssuer(v.Issuer),
+	}
+	if v.Audience != "" {
+		checks = append(checks, ValidateAudience(v.Audience))
+	}
+	token, err := parseWith(raw, v.Algorithm, v.KeySet, v.Options)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSecurityEvent(token, append(checks, validators...)); err != nil {
+		return nil, err
+	}
+	return newSecurityEvent(&token.Claims)
+}
+
+// validateSecurityEvent checks the time based claims, which are optional except "iat", and
+// runs the validators.
+func validateSecurityEvent(token *JwtToken, validators []Validator) error {
+	now := time.Now().Unix()
+	if token.Claims.Expires != 0 && token.Claims.Expires < now {
+		return ErrTokenExpired
+	}
+	if token.Claims.NotBefore > now {
+		return ErrTokenNotValidYet
+	}
+	if _, ok := token.Claims.Raw["nonce"]; ok {
+		return ErrEventNonce
+	}
+	for _, validator := range validators {
+		if err := validator(token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newSecurityEvent reads the security event claims from the standard claims.
+func newSecurityEvent(claims *Claims) (*SecurityEvent, error) {
+	event := &SecurityEvent{Claims: *claims}
+	events, ok := claims.Raw["events"].(map[string]interface{})
+	if !ok || len(events) == 0 {
+		return nil, ErrInvalidEvents
+	}
+	for _, payload := range events {
+		if _, ok := payload.(map[string]interface{}); !ok {
+			return nil, ErrInvalidEvents
+		}
+	}
+	event.Events = events
+	var err error
+	if event.ID, err = stringMember(claims.Raw, "jti"); err != nil {
+		return nil, err
+	}
+	if event.TransactionID, err = stringMember(claims.Raw, "txn"); err != nil {
+		return nil, err
+	}
+	if event.TimeOfEvent, err = intMember(claims.Raw, "toe"); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// LogoutToken holds the claims of an OpenID Connect back-channel logout token.
+type LogoutToken struct {
+	Claims
+	// ID is the "jti" claim.
+	ID string
+	// SessionID is the "sid" claim.
+	SessionID string
+}
+
+// CreateLogoutToken creates a logout token with the "typ" header "logout+jwt" and the
+// back-channel logout event. The "iss", "aud" and "exp" claims and one of "sub" and "sid" are
+// required. A random "jti" is generated if ID is empty.
+func CreateLogoutToken(token *LogoutToken, algorithm Algorithm, opts ...Option) (string, error) {
+	if token == nil {
+		return "", errors.New("Logout token can't be nil")
+	}
+	if err := requireClaims("aud", "exp")(&JwtToken{Claims: token.Claims}); err != nil {
+		return "", err
+	}
+	if token.Subject == "" && token.SessionID == "" {
+		return "", &MissingClaimError{"sid"}
+	}
+	return createSecurityEvent(token.event(), TYP_LOGOUT_JWT, algorithm, opts)
+}
+
+// MarshalJSON encodes the logout token claims together with the standard claims.
+func (t LogoutToken) MarshalJSON() ([]byte, error) {
+	return t.event().MarshalJSON()
+}
+
+// UnmarshalJSON decodes the standard claims and the logout token claims.
+func (t *LogoutToken) UnmarshalJSON(data []byte) error {
+	var claims Claims
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return err
+	}
+	token, err := newLogoutToken(&claims)
+	if err != nil {
+		return err
+	}
+	*t = *token
+	return nil
+}
+
+// event returns the security event with the back-channel logout event.
+func (t LogoutToken) event() *SecurityEvent {
+	event := &SecurityEvent{
+		Claims: t.Claims,
+		ID:     t.ID,
+		Events: map[string]interface{}{BACKCHANNEL_LOGOUT_EVENT: map[string]interface{}{}},
+	}
+	if t.SessionID != "" {
+		event.Raw = map[string]interface{}{}
+		for name, value := range t.Raw {
+			event.Raw[name] = value
+		}
+		event.Raw["sid"] = t.SessionID
+	}
+	return event
+}
+
+// newLogoutToken reads the logout token claims from the standard claims.
+func newLogoutToken(claims *Claims) (*LogoutToken, error) {
+	token := &LogoutToken{Claims: *claims}
+	var err error
+	if token.ID, err = stringMember(claims.Raw, "jti"); err != nil {
+		return nil, err
+	}
+	if token.SessionID, err = stringMember(claims.Raw, "sid"); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// LogoutTokenVerifier verifies logout tokens at the back-channel logout endpoint of a relying
+// party (OpenID Connect Back-Channel Logout 1.0 section 2.6). Tokens with the "typ" header
+// "logout+jwt" and tokens without explicit type are accepted.
+type LogoutTokenVerifier struct {
+	// Issuer is the expected "iss" claim.
+	Issuer string
+	// ClientID is the client identifier of the relying party, which must be one of the audiences.
+	ClientID string
+	// Algorithm verifies the token signature.
+	Algorithm Algorithm
+	// KeySet verifies the token signature with the key matching the "kid" header. It takes
+	// precedence over Algorithm.
+	KeySet *KeySet
+	// Options are applied when parsing the token.
+	Options []Option
+}
+
+// NewLogoutTokenVerifier creates a new logout token verifier.
+func NewLogoutTokenVerifier(issuer, clientID string, algorithm Algorithm) *LogoutTokenVerifier {
+	return &LogoutTokenVerifier{Issuer: issuer, ClientID: clientID, Algorithm: algorithm}
+}
+
+// Verify parses and validates a logout token. The validators are run after the checks of the
+// verifier.
+func (v *LogoutTokenVerifier) Verify(raw string, validators ...Validator) (*LogoutToken, error) {
+	if v.Issuer == "" || v.ClientID == "" {
+		return nil, errors.New("Issuer and client ID can't be empty")
+	}
+	checks := []Validator{
+		ValidateType(TYP_LOGOUT_JWT, "jwt", ""),
+		requireClaims("iss", "aud", "iat", "exp", "jti", "events"),
+		ValidateIssuer(v.Issuer),
+		ValidateAudience(v.ClientID),
+		func(token *JwtToken) error {
+			if _, ok := token.Claims.Raw["nonce"]; ok {
+				return ErrEventNonce
+			}
+			events, _ := token.Claims.Raw["events"].(map[string]interface{})
+			if _, ok := events[BACKCHANNEL_LOGOUT_EVENT].(map[string]interface{}); !ok {
+				return ErrInvalidEvents
+			}
+			if token.Claims.Subject == "" {
+				if sid, _ := token.Claims.Raw["sid"].(string); sid == "" {
+					return &MissingClaimError{"sid"}
+				}
+			}
+			return nil
+		},
+	}
+	token, err := parseAndValidate(raw, v.Algorithm, v.KeySet, append(checks, validators...), v.Options)
+	if err != nil {
+		return nil, err
+	}
+	return newLogoutToken(&token.Claims)
+}
@@ -0,0 +1,175 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+const (
+	verificationEvent = "https://schemas.openid.net/secevent/risc/event-type/verification"
+	sessionRevoked    = "https://schemas.openid.net/secevent/caep/event-type/session-revoked"
+)
+
+func TestSecurityEvent(t *testing.T) {
+	rs256, _ := NewRS256(mustReadFixture("rsa"))
+	raw, err := CreateSecurityEvent(&SecurityEvent{
+		Claims:        Claims{Issuer: atIssuer, Audience: atResource, Subject: "user"},
+		Events:        map[string]interface{}{sessionRevoked: map[string]interface{}{"reason": "logout"}},
+		TransactionID: "txn",
+		TimeOfEvent:   time.Now().Unix(),
+	}, rs256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, err := NewSecurityEventVerifier(atIssuer, atResource, rs256).Verify(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, _ := event.Events[sessionRevoked].(map[string]interface{})
+	if event.ID == "" || event.TransactionID != "txn" || event.TimeOfEvent == 0 || payload["reason"] != "logout" || event.Subject != "user" {
+		t.Log(event)
+		t.Fail()
+	}
+	if _, err := NewSecurityEventVerifier(atIssuer, "https://other.example.com", rs256).Verify(raw); err == nil {
+		t.Fail()
+	}
+	// access tokens are not security events
+	accessToken, _ := CreateAccessToken(testAccessToken(), rs256)
+	if _, err := NewSecurityEventVerifier(atIssuer, atResource, rs256).Verify(accessToken); err != ErrInvalidType {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestSecurityEventRules(t *testing.T) {
+	rs256, _ := NewRS256(mustReadFixture("rsa"))
+	events := map[string]interface{}{verificationEvent: map[string]interface{}{}}
+	_, err := CreateSecurityEvent(&SecurityEvent{Claims: Claims{Issuer: atIssuer, Raw: map[string]interface{}{"nonce": "n"}}, Events: events}, rs256)
+	if err != ErrEventNonce {
+		t.Log(err)
+		t.Fail()
+	}
+	_, err = CreateSecurityEvent(&SecurityEvent{Claims: Claims{Issuer: atIssuer}}, rs256)
+	if _, ok := err.(*MissingClaimError); !ok {
+		t.Log(err)
+		t.Fail()
+	}
+	_, err = CreateSecurityEvent(&SecurityEvent{Events: events}, rs256)
+	if _, ok := err.(*MissingClaimError); !ok {
+		t.Log(err)
+		t.Fail()
+	}
+	verifier := NewSecurityEventVerifier(atIssuer, "", rs256)
+	for _, claims := range []*Claims{
+		{Issuer: atIssuer, Raw: map[string]interface{}{"jti": "1", "events": events, "nonce": "n"}},
+		{Issuer: atIssuer, Raw: map[string]interface{}{"jti": "1", "events": map[string]interface{}{}}},
+		{Issuer: atIssuer, Raw: map[string]interface{}{"jti": "1", "events": map[string]interface{}{verificationEvent: "x"}}},
+		{Issuer: atIssuer, Expires: time.Now().Add(-time.Minute).Unix(), Raw: map[string]interface{}{"jti": "1", "events": events}},
+		{Issuer: atIssuer, Raw: map[string]interface{}{"events": events}},
+	} {
+		raw, _ := Create(claims, rs256, WithType(TYP_SECEVENT_JWT))
+		if _, err := verifier.Verify(raw); err == nil {
+			t.Log(claims.Raw)
+			t.Fail()
+		}
+	}
+}
+
+func TestSecurityEventJSON(t *testing.T) {
+	event := &SecurityEvent{
+		Claims:        Claims{Issuer: atIssuer},
+		ID:            "id",
+		Events:        map[string]interface{}{sessionRevoked: map[string]interface{}{"reason": "logout"}},
+		TransactionID: "txn",
+		TimeOfEvent:   1600000000,
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &SecurityEvent{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.ID != "id" || decoded.Issuer != atIssuer || decoded.TransactionID != "txn" || decoded.TimeOfEvent != 1600000000 || decoded.Events[sessionRevoked] == nil {
+		t.Log(string(data))
+		t.Fail()
+	}
+	data, err = json.Marshal(&LogoutToken{Claims: Claims{Issuer: oidcIssuer}, ID: "id", SessionID: "sid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logout := &LogoutToken{}
+	if err := json.Unmarshal(data, logout); err != nil {
+		t.Fatal(err)
+	}
+	if logout.ID != "id" || logout.SessionID != "sid" || logout.Raw["events"] == nil {
+		t.Log(string(data))
+		t.Fail()
+	}
+}
+
+func TestLogoutToken(t *testing.T) {
+	rs256, _ := NewRS256(mustReadFixture("rsa"))
+	raw, err := CreateLogoutToken(&LogoutToken{
+		Claims:    Claims{Issuer: oidcIssuer, Audience: oidcClientID, Expires: time.Now().Add(2 * time.Minute).Unix()},
+		SessionID: "session",
+	}, rs256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := NewLogoutTokenVerifier(oidcIssuer, oidcClientID, rs256)
+	logout, err := verifier.Verify(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logout.SessionID != "session" || logout.ID == "" {
+		t.Log(logout)
+		t.Fail()
+	}
+	// security events of other types are not logout tokens
+	event, _ := CreateSecurityEvent(&SecurityEvent{
+		Claims: Claims{Issuer: oidcIssuer, Audience: oidcClientID, Subject: "user", Expires: time.Now().Add(time.Minute).Unix()},
+		Events: map[string]interface{}{BACKCHANNEL_LOGOUT_EVENT: map[string]interface{}{}},
+	}, rs256)
+	if _, err := verifier.Verify(event); err != ErrInvalidType {
+		t.Log(err)
+		t.Fail()
+	}
+	// ID tokens don't carry the logout event
+	idToken, _ := Create(&Claims{Issuer: oidcIssuer, Audience: oidcClientID, Subject: "user", Expires: time.Now().Add(time.Minute).Unix(),
+		Raw: map[string]interface{}{"jti": "1", "events": map[string]interface{}{}}}, rs256)
+	if _, err := verifier.Verify(idToken); err != ErrInvalidEvents {
+		t.Log(err)
+		t.Fail()
+	}
+	_, err = CreateLogoutToken(&LogoutToken{Claims: Claims{Issuer: oidcIssuer, Audience: oidcClientID, Expires: time.Now().Unix()}}, rs256)
+	if _, ok := err.(*MissingClaimError); !ok {
+		t.Log(err)
+		t.Fail()
+	}
+}
@@ -0,0 +1,181 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// THUMBPRINT_URI_PREFIX is the prefix of JWK thumbprint URIs (RFC 9278).
+const THUMBPRINT_URI_PREFIX = "urn:ietf:params:oauth:jwk-thumbprint:"
+
+// thumbprintHashes maps the hash functions to their names in the IANA Named Information Hash
+// Algorithm Registry, which are used in thumbprint URIs.
+var thumbprintHashes = map[crypto.Hash]string{
+	crypto.SHA256: "sha-256",
+	crypto.SHA384: "sha-384",
+	crypto.SHA512: "sha-512",
+}
+
+// Thumbprint returns the base64url encoded SHA-256 JWK thumbprint of the key (RFC 7638).
+// Public and private keys have the same thumbprint.
+func (j *JWK) Thumbprint() (string, error) {
+	return j.ThumbprintWith(crypto.SHA256)
+}
+
+// ThumbprintWith returns the base64url encoded JWK thumbprint of the key computed with the hash.
+// SHA-256, SHA-384 and SHA-512 are supported.
+func (j *JWK) ThumbprintWith(hash crypto.Hash) (string, error) {
+	if _, ok := thumbprintHashes[hash]; !ok {
+		return "", errors.New("Unsupported thumbprint hash")
+	}
+	var members map[string]string
+	switch j.Kty {
+	case "RSA":
+		members = map[string]string{"e": j.E, "kty": j.Kty, "n": j.N}
+	case "EC":
+		members = map[string]string{"crv": j.Crv, "kty": j.Kty, "x": j.X, "y": j.Y}
+	case "OKP":
+		members = map[string]string{"crv": j.Crv, "kty": j.Kty, "x": j.X}
+	case "oct":
+		members = map[string]string{"k": j.K, "kty": j.Kty}
+	default:
+		return "", errors.New("Unsupported key type: " + j.Kty)
+	}
+	for name, value := range members {
+		if value == "" {
+			return "", errors.New("JWK member is missing: " + name)
+		}
+	}
+	// maps are encoded with sorted keys and without whitespace
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(digest(hash, data)), nil
+}
+
+// ThumbprintURI returns the JWK thumbprint URI of the key computed with the hash (RFC 9278),
+// e.g. "urn:ietf:params:oauth:jwk-thumbprint:sha-256:NzbLsXh8...".
+func (j *JWK) ThumbprintURI(hash crypto.Hash) (string, error) {
+	thumbprint, err := j.ThumbprintWith(hash)
+	if err != nil {
+		return "", err
+	}
+	return THUMBPRINT_URI_PREFIX + thumbprintHashes[hash] + ":" + thumbprint, nil
+}
+
+// ParseThumbprintURI returns the hash function and the thumbprint of a JWK thumbprint URI.
+func ParseThumbprintURI(uri string) (crypto.Hash, string, error) {
+	if !strings.HasPrefix(uri, THUMBPRINT_URI_PREFIX) {
+		return 0, "", errors.New("Invalid thumbprint URI")
+	}
+	name, thumbprint, ok := strings.Cut(uri[len(THUMBPRINT_URI_PREFIX):], ":")
+	if !ok || thumbprint == "" {
+		return 0, "", errors.New("Invalid thumbprint URI")
+	}
+	for hash, hashName := range thumbprintHashes {
+		if hashName == name {
+			if _, err := base64.RawURLEncoding.Strict().DecodeString(thumbprint); err != nil {
+				return 0, "", errors.New("Invalid thumbprint URI")
+			}
+			return hash, thumbprint, nil
+		}
+	}
+	return 0, "", errors.New("Unsupported thumbprint hash: " + name)
+}
+
+// FindThumbprint returns the key with the thumbprint, which is either a SHA-256 thumbprint
+// or a thumbprint URI. It returns nil if the set has no such key.
+func (s *JWKS) FindThumbprint(thumbprint string) *JWK {
+	hash := crypto.SHA256
+	if strings.HasPrefix(thumbprint, THUMBPRINT_URI_PREFIX) {
+		var err error
+		if hash, thumbprint, err = ParseThumbprintURI(thumbprint); err != nil {
+			return nil
+		}
+	}
+	for _, key := range s.Keys {
+		if value, err := key.ThumbprintWith(hash); err == nil && value == thumbprint {
+			return key
+		}
+	}
+	return nil
+}
+
+// WithThumbprintKeyID sets the "kid" header of tokens created with Create to the SHA-256 JWK
+// thumbprint of the public key of the algorithm, unless the algorithm is a Key with its own
+// identifier. HMAC algorithms are not supported, as the thumbprint would be derived from the secret.
+func WithThumbprintKeyID() Option {
+	return func(o *options) {
+		o.thumbprintKid = true
+	}
+}
+
+// algorithmThumbprint returns the SHA-256 JWK thumbprint of the public key of an algorithm.
+func algorithmThumbprint(algorithm Algorithm) (string, error) {
+	publicKey, err := algorithmPublicKey(algorithm)
+	if err != nil {
+		return "", err
+	}
+	jwk, err := NewJWK(publicKey)
+	if err != nil {
+		return "", err
+	}
+	return jwk.Thumbprint()
+}
+
+// algorithmPublicKey returns the public key of an asymmetric algorithm of this package.
+func algorithmPublicKey(algorithm Algorithm) (crypto.PublicKey, error) {
+	if key, ok := algorithm.(*Key); ok {
+		algorithm = key.Algorithm
+	}
+	switch a := algorithm.(type) {
+	case *RS256:
+		return a.rsa.publicKey, nil
+	case *RS384:
+		return a.rsa.publicKey, nil
+	case *RS512:
+		return a.rsa.publicKey, nil
+	case *PS256:
+		return a.rsa.publicKey, nil
+	case *PS384:
+		return a.rsa.publicKey, nil
+	case *PS512:
+		return a.rsa.publicKey, nil
+	case *ES256:
+		return a.ecdsa.publicKey, nil
+	case *ES384:
+		return a.ecdsa.publicKey, nil
+	case *ES512:
+		return a.ecdsa.publicKey, nil
+	case *EdDSA:
+		return a.publicKey, nil
+	}
+	return nil, errors.New("Thumbprints require an asymmetric algorithm of this package")
+}
@@ -0,0 +1,131 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"strings"
+	"testing"
+)
+
+func TestJWKThumbprint(t *testing.T) {
+	// RFC 7638 section 3.1
+	jwk := &JWK{
+		Kty: "RSA",
+		Kid: "2011-04-29",
+		Alg: JWT_RS256,
+		E:   "AQAB",
+		N:   "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+	}
+	thumbprint, err := jwk.Thumbprint()
+	if err != nil || thumbprint != "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs" {
+		t.Log(thumbprint, err)
+		t.Fail()
+	}
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	private, _ := NewJWK(key)
+	a, _ := private.Thumbprint()
+	b, _ := private.Public().Thumbprint()
+	if a != b {
+		t.Log(a, b)
+		t.Fail()
+	}
+	_, err = (&JWK{Kty: "EC", Crv: "P-256", X: private.X}).Thumbprint()
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestJWKThumbprintURI(t *testing.T) {
+	// RFC 9278 section 3
+	jwk := &JWK{Kty: "RSA", E: "AQAB", N: "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw"}
+	uri, err := jwk.ThumbprintURI(crypto.SHA256)
+	if err != nil || uri != "urn:ietf:params:oauth:jwk-thumbprint:sha-256:NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs" {
+		t.Log(uri, err)
+		t.Fail()
+	}
+	uri, _ = jwk.ThumbprintURI(crypto.SHA512)
+	hash, thumbprint, err := ParseThumbprintURI(uri)
+	if err != nil || hash != crypto.SHA512 || len(thumbprint) != 86 {
+		t.Log(hash, thumbprint, err)
+		t.Fail()
+	}
+	set := &JWKS{Keys: []*JWK{{Kty: "oct", K: "c2VjcmV0"}, jwk}}
+	if set.FindThumbprint(uri) != jwk || set.FindThumbprint("NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs") != jwk {
+		t.Fail()
+	}
+	if set.FindThumbprint(strings.Replace(uri, "sha-512", "sha-384", 1)) != nil {
+		t.Fail()
+	}
+	for _, uri := range []string{
+		"urn:ietf:params:oauth:jwk-thumbprint:sha-256",
+		"urn:ietf:params:oauth:jwk-thumbprint:md5:NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs",
+		"urn:ietf:params:oauth:jwk-thumbprint:sha-256:NzbLsXh8+DCcd",
+		"urn:example:sha-256:NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs",
+	} {
+		if _, _, err := ParseThumbprintURI(uri); err == nil {
+			t.Log(uri)
+			t.Fail()
+		}
+	}
+	if _, err := jwk.ThumbprintWith(crypto.MD5); err == nil {
+		t.Fail()
+	}
+}
+
+func TestThumbprintKeyID(t *testing.T) {
+	for _, name := range algorithms {
+		key, err := GenerateKey(name)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		token, err := Create(&Claims{}, key.Algorithm, WithThumbprintKeyID())
+		if strings.HasPrefix(name, "HS") {
+			if err == nil {
+				t.Log(name)
+				t.Fail()
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		unverified, _ := Decode(token)
+		expected, _ := key.PublicJWK.Thumbprint()
+		if unverified.Header.Kid != expected {
+			t.Log(name, unverified.Header.Kid, expected)
+			t.Fail()
+		}
+	}
+	rs256, _ := NewRS256(mustReadFixture("rsa"))
+	key, _ := NewKey("key1", rs256)
+	token, _ := Create(&Claims{}, key, WithThumbprintKeyID())
+	if unverified, _ := Decode(token); unverified.Header.Kid != "key1" {
+		t.Log(unverified.Header.Kid)
+		t.Fail()
+	}
+}
@@ -0,0 +1,53 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import "errors"
+
+// Unsecured provides methods for creating and parsing unsecured JWTs ("alg": "none").
+// It can only be used with the DangerouslyAllowUnsecured option and never in key sets.
+type Unsecured struct{}
+
+// NewUnsecured creates a new Unsecured helper.
+func NewUnsecured() *Unsecured {
+	return &Unsecured{}
+}
+
+// Sign returns an empty signature.
+func (e *Unsecured) Sign(data []byte) ([]byte, error) {
+	return []byte{}, nil
+}
+
+// Verify verifies that the signature is empty.
+func (e *Unsecured) Verify(data []byte, signature []byte) error {
+	if len(signature) != 0 {
+		return errors.New("Unsecured JWTs must not have a signature")
+	}
+	return nil
+}
+
+// Name returns the the JWT algorithm name.
+func (e *Unsecured) Name() string {
+	return JWT_NONE
+}
@@ -0,0 +1,121 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnsecured(t *testing.T) {
+	claims := &Claims{Subject: "subject", Expires: time.Now().Add(time.Hour).Unix()}
+	tokenString, err := Create(claims, NewUnsecured(), DangerouslyAllowUnsecured())
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if !strings.HasSuffix(tokenString, ".") {
+		t.Log(tokenString)
+		t.Fail()
+	}
+	token, err := Parse(tokenString, NewUnsecured(), DangerouslyAllowUnsecured())
+	if err != nil || token.Claims.Subject != "subject" || token.Header.Alg != JWT_NONE {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestUnsecuredNotAllowed(t *testing.T) {
+	_, err := Create(&Claims{}, NewUnsecured())
+	if err != ErrAlgorithmNone {
+		t.Log(err)
+		t.Fail()
+	}
+	tokenString, _ := Create(&Claims{}, NewUnsecured(), DangerouslyAllowUnsecured())
+	_, err = Parse(tokenString, NewUnsecured())
+	if err != ErrAlgorithmNone {
+		t.Log(err)
+		t.Fail()
+	}
+	hs256, _ := NewHS256(testSecret("secret"))
+	_, err = Parse(tokenString, hs256, DangerouslyAllowUnsecured())
+	if err != ErrInvalidAlgorithm {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestUnsecuredWithSignature(t *testing.T) {
+	hs256, _ := NewHS256(testSecret("secret"))
+	signed, _ := Create(&Claims{}, hs256)
+	unsecured, _ := Create(&Claims{}, NewUnsecured(), DangerouslyAllowUnsecured())
+	tokenString := unsecured + signed[strings.LastIndex(signed, ".")+1:]
+	_, err := Parse(tokenString, NewUnsecured(), DangerouslyAllowUnsecured())
+	if err != ErrInvalidSignature {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+// fakeNone is an algorithm which pretends to be the unsecured algorithm.
+type fakeNone struct{ Unsecured }
+
+func (f *fakeNone) Name() string {
+	return "None"
+}
+
+func TestUnsecuredOnlyWithUnsecuredType(t *testing.T) {
+	_, err := Create(&Claims{}, &fakeNone{}, DangerouslyAllowUnsecured())
+	if err != ErrAlgorithmNone {
+		t.Log(err)
+		t.Fail()
+	}
+	tokenString, _ := Create(&Claims{}, NewUnsecured(), DangerouslyAllowUnsecured())
+	_, err = Parse(tokenString, &fakeNone{}, DangerouslyAllowUnsecured())
+	if err != ErrAlgorithmNone {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestUnsecuredKeySet(t *testing.T) {
+	_, err := NewKey("key1", NewUnsecured())
+	if err != ErrAlgorithmNone {
+		t.Log(err)
+		t.Fail()
+	}
+	set := NewKeySet(&Key{NewUnsecured(), "key1"})
+	_, err = Create(&Claims{}, &Key{NewUnsecured(), "key1"}, DangerouslyAllowUnsecured())
+	if err != ErrAlgorithmNone {
+		t.Log(err)
+		t.Fail()
+	}
+	tokenString := forge(`{"alg":"none","typ":"JWT","kid":"key1"}`, &Claims{}, nil)
+	_, err = ParseWithKeySet(tokenString, set)
+	if err != ErrAlgorithmNone {
+		t.Log(err)
+		t.Fail()
+	}
+}
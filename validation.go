@@ -0,0 +1,151 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrTokenExpired     = errors.New("Token is expired")
+	ErrTokenNotValidYet = errors.New("Token is not valid yet")
+	ErrInvalidType      = errors.New("Invalid token type")
+)
+
+// Validator validates a parsed token and returns an error if the token is not acceptable.
+type Validator func(token *JwtToken) error
+
+// InsufficientScopeError is returned by validators if a token lacks the scopes or roles required for a request.
+type InsufficientScopeError struct {
+	Missing      []string
+	MissingRoles []string
+}
+
+func (e *InsufficientScopeError) Error() string {
+	message := "Insufficient scope."
+	if len(e.Missing) > 0 {
+		message += " Missing scopes: " + strings.Join(e.Missing, " ") + "."
+	}
+	if len(e.MissingRoles) > 0 {
+		message += " Missing roles: " + strings.Join(e.MissingRoles, " ") + "."
+	}
+	return message
+}
+
+// Validate checks the time based claims of a token and runs the given validators in order.
+func (t *JwtToken) Validate(validators ...Validator) error {
+	if t.IsExpired() {
+		return ErrTokenExpired
+	}
+	if t.Claims.NotBefore > time.Now().Unix() {
+		return ErrTokenNotValidYet
+	}
+	for _, validator := range validators {
+		if err := validator(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseAndValidate parses a token with the key set, or the algorithm if the key set is nil,
+// and validates it.
+func ParseAndValidate(raw string, algorithm Algorithm, keys *KeySet, validators ...Validator) (*JwtToken, error) {
+	return parseAndValidate(raw, algorithm, keys, validators, nil)
+}
+
+func parseAndValidate(raw string, algorithm Algorithm, keys *KeySet, validators []Validator, opts []Option) (*JwtToken, error) {
+	token, err := parseWith(raw, algorithm, keys, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := token.Validate(validators...); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// parseWith parses a token with the key set, or the algorithm if the key set is nil.
+func parseWith(raw string, algorithm Algorithm, keys *KeySet, opts []Option) (*JwtToken, error) {
+	switch {
+	case keys != nil:
+		return ParseWithKeySet(raw, keys, opts...)
+	case algorithm != nil:
+		return Parse(raw, algorithm, opts...)
+	}
+	return nil, errors.New("Algorithm can't be nil")
+}
+
+// ValidateIssuer returns a validator which checks the "iss" claim.
+func ValidateIssuer(issuer string) Validator {
+	return func(token *JwtToken) error {
+		if token.Claims.Issuer != issuer {
+			return errors.New("Invalid issuer")
+		}
+		return nil
+	}
+}
+
+// ValidateAudience returns a validator which checks that the audience is one of the
+// audiences of the "aud" claim.
+func ValidateAudience(audience string) Validator {
+	return func(token *JwtToken) error {
+		if !contains(token.Claims.Audiences(), audience) {
+			return errors.New("Invalid audience")
+		}
+		return nil
+	}
+}
+
+// ValidateType returns a validator which checks that the "typ" header is one of the types.
+// Types are compared case-insensitively and the "application/" prefix is ignored
+// (RFC 7515 section 4.1.9).
+func ValidateType(types ...string) Validator {
+	return func(token *JwtToken) error {
+		typ := strings.TrimPrefix(strings.ToLower(token.Header.Typ), "application/")
+		for _, t := range types {
+			if typ == strings.TrimPrefix(strings.ToLower(t), "application/") {
+				return nil
+			}
+		}
+		return ErrInvalidType
+	}
+}
+
+// Audiences returns the audiences of the token. The "aud" claim may be a string or an
+// array of strings (RFC 7519 section 4.1.3).
+func (c *Claims) Audiences() []string {
+	switch audiences := c.Raw["aud"].(type) {
+	case []interface{}:
+		return stringValues(audiences)
+	case []string:
+		return append([]string{}, audiences...)
+	}
+	if c.Audience == "" {
+		return []string{}
+	}
+	return []string{c.Audience}
+}
@@ -0,0 +1,84 @@
+/*
+MIT License
+
+Copyright (c) 2022 Róbert Tézli (robert.tezli+github@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package jwt
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	token := &JwtToken{Claims: Claims{Expires: time.Now().Add(time.Hour).Unix(), Issuer: "issuer", Audience: "audience"}}
+	err := token.Validate(ValidateIssuer("issuer"), ValidateAudience("audience"))
+	if err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestValidateExpired(t *testing.T) {
+	token := &JwtToken{Claims: Claims{Expires: time.Now().Add(-time.Hour).Unix()}}
+	err := token.Validate()
+	if err != ErrTokenExpired {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestValidateNotBefore(t *testing.T) {
+	token := &JwtToken{Claims: Claims{
+		Expires:   time.Now().Add(time.Hour).Unix(),
+		NotBefore: time.Now().Add(time.Minute).Unix(),
+	}}
+	err := token.Validate()
+	if err != ErrTokenNotValidYet {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestValidateIssuerAndAudience(t *testing.T) {
+	token := &JwtToken{Claims: Claims{Expires: time.Now().Add(time.Hour).Unix(), Issuer: "issuer", Audience: "audience"}}
+	err := token.Validate(ValidateIssuer("other"))
+	if err == nil {
+		t.Log(err)
+		t.Fail()
+	}
+	err = token.Validate(ValidateAudience("other"))
+	if err == nil {
+		t.Log(err)
+		t.Fail()
+	}
+}
+
+func TestValidateCustomValidator(t *testing.T) {
+	token := &JwtToken{Claims: Claims{Expires: time.Now().Add(time.Hour).Unix()}}
+	custom := errors.New("custom")
+	err := token.Validate(func(*JwtToken) error { return custom })
+	if err != custom {
+		t.Log(err)
+		t.Fail()
+	}
+}